@@ -0,0 +1,225 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Matcher string
+
+const (
+	MatchEqual    Matcher = "equal"
+	MatchContains Matcher = "contains"
+	MatchRegex    Matcher = "regex"
+	// MatchAtMost compares numbers, the actual one must not be greater, a percentage like "0.5%" is accepted too
+	MatchAtMost Matcher = "atMost"
+)
+
+// Match compares the actual value to the expected one, in regex mode expected is the pattern
+func (m Matcher) Match(actual, expected string) (bool, error) {
+	switch m {
+	case MatchEqual, "":
+		return actual == expected, nil
+	case MatchContains:
+		return strings.Contains(actual, expected), nil
+	case MatchRegex:
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(actual), nil
+	case MatchAtMost:
+		a, err := parseNumber(actual)
+		if err != nil {
+			return false, err
+		}
+		e, err := parseNumber(expected)
+		if err != nil {
+			return false, err
+		}
+		return a <= e, nil
+	}
+
+	return false, fmt.Errorf("unknown matcher %q", string(m))
+}
+
+type AssertMode int
+
+const (
+	// AssertHard stops the running actions at the first failing assertion
+	AssertHard AssertMode = iota
+	// AssertSoft collects the failures, GroupProcess reports them after all actions ran
+	AssertSoft
+)
+
+type AssertionError struct {
+	Assertion string
+	Subject   string
+	Matcher   Matcher
+	Expected  string
+	Actual    string
+}
+
+func (ae AssertionError) Error() string {
+	return fmt.Sprintf(`%s failed on %s: expected %s %q, got %q`, ae.Assertion, ae.Subject, ae.Matcher, ae.Expected, ae.Actual)
+}
+
+type AssertionErrors []AssertionError
+
+func (aes AssertionErrors) Error() string {
+	var messages []string
+	for _, ae := range aes {
+		messages = append(messages, ae.Error())
+	}
+
+	return fmt.Sprintf("%d assertion(s) failed:\n%s", len(aes), strings.Join(messages, "\n"))
+}
+
+func (sm *SiteManager) SetAssertMode(mode AssertMode) {
+	sm.assertMode = mode
+}
+
+func (sm SiteManager) GetAssertMode() AssertMode {
+	return sm.assertMode
+}
+
+// AssertionFailures returns the failures collected in soft mode
+func (sm SiteManager) AssertionFailures() AssertionErrors {
+	return sm.assertFailures
+}
+
+func (sm *SiteManager) ClearAssertionFailures() {
+	sm.assertFailures = nil
+}
+
+func (sm *SiteManager) AssertText(selector string, matcher Matcher, expected string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	var actual string
+	action := sm.assertAction("AssertText", selector, matcher, expected, &actual, chromedp.Text(selector, &actual, options...))
+	if sm.activeGroup != "" {
+		sm.addGroupAction("AssertText", []interface{}{selector, matcher, expected}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) AssertAttribute(selector string, attribute string, matcher Matcher, expected string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	var actual string
+	var ok bool
+	fetch := chromedp.Tasks{
+		chromedp.AttributeValue(selector, attribute, &actual, &ok, options...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if !ok {
+				actual = "<missing attribute>"
+			}
+			return nil
+		}),
+	}
+	action := sm.assertAction("AssertAttribute", fmt.Sprintf("%s@%s", selector, attribute), matcher, expected, &actual, fetch)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("AssertAttribute", []interface{}{selector, attribute, matcher, expected}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// AssertCount checks the number of elements matching the selector at the time the action runs, it does not wait for them
+func (sm *SiteManager) AssertCount(selector string, expected int, timeoutSec int64, handleError bool) error {
+	var actual string
+	var nodes []*cdp.Node
+	fetch := chromedp.Tasks{
+		chromedp.Nodes(selector, &nodes, chromedp.AtLeast(0)),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			actual = strconv.Itoa(len(nodes))
+			return nil
+		}),
+	}
+	action := sm.assertAction("AssertCount", selector, MatchEqual, strconv.Itoa(expected), &actual, fetch)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("AssertCount", []interface{}{selector, expected}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) AssertURL(matcher Matcher, expected string, timeoutSec int64, handleError bool) error {
+	var actual string
+	action := sm.assertAction("AssertURL", "url", matcher, expected, &actual, chromedp.Location(&actual))
+	if sm.activeGroup != "" {
+		sm.addGroupAction("AssertURL", []interface{}{matcher, expected}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) AssertTitle(matcher Matcher, expected string, timeoutSec int64, handleError bool) error {
+	var actual string
+	action := sm.assertAction("AssertTitle", "title", matcher, expected, &actual, chromedp.Title(&actual))
+	if sm.activeGroup != "" {
+		sm.addGroupAction("AssertTitle", []interface{}{matcher, expected}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// assertAction runs fetch to fill actual, then compares it with expected by the matcher
+func (sm *SiteManager) assertAction(assertion, subject string, matcher Matcher, expected string, actual *string, fetch chromedp.Action) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := fetch.Do(ctx); err != nil {
+			return err
+		}
+
+		ok, err := matcher.Match(*actual, expected)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		return sm.assertFailed(AssertionError{
+			Assertion: assertion,
+			Subject:   subject,
+			Matcher:   matcher,
+			Expected:  expected,
+			Actual:    *actual,
+		})
+	})
+}
+
+func (sm *SiteManager) assertFailed(ae AssertionError) error {
+	if sm.assertMode == AssertSoft {
+		sm.assertFailures = append(sm.assertFailures, ae)
+		return nil
+	}
+
+	return ae
+}
+
+func parseNumber(value string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+}
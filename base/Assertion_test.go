@@ -0,0 +1,88 @@
+package base
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	tests := []struct {
+		matcher  Matcher
+		actual   string
+		expected string
+		want     bool
+		wantErr  bool
+	}{
+		{MatchEqual, "Example Domain", "Example Domain", true, false},
+		{MatchEqual, "Example Domain", "Example", false, false},
+		{"", "same", "same", true, false},
+		{MatchContains, "Example Domain", "Domain", true, false},
+		{MatchContains, "Example Domain", "domain", false, false},
+		{MatchRegex, "order #1234", `#\d+$`, true, false},
+		{MatchRegex, "order #12a", `#\d+$`, false, false},
+		{MatchRegex, "anything", `(`, false, true},
+		{MatchAtMost, "0.5", "1", true, false},
+		{MatchAtMost, "1", "1", true, false},
+		{MatchAtMost, "1.0001%", "1%", false, false},
+		{MatchAtMost, " 0.25% ", "0.5%", true, false},
+		{MatchAtMost, "many", "1", false, true},
+		{MatchAtMost, "1", "few", false, true},
+		{Matcher("starts with"), "abc", "a", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.matcher)+"/"+tt.actual, func(t *testing.T) {
+			got, err := tt.matcher.Match(tt.actual, tt.expected)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Match(%q, %q) error = %v, wantErr %v", tt.actual, tt.expected, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.actual, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssertionErrorsError(t *testing.T) {
+	aes := AssertionErrors{
+		{Assertion: "AssertTitle", Subject: "title", Matcher: MatchEqual, Expected: "Home", Actual: "Login"},
+		{Assertion: "AssertCount", Subject: "li", Matcher: MatchEqual, Expected: "3", Actual: "2"},
+	}
+
+	want := "2 assertion(s) failed:\n" +
+		`AssertTitle failed on title: expected equal "Home", got "Login"` + "\n" +
+		`AssertCount failed on li: expected equal "3", got "2"`
+	if got := aes.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestAssertFailed(t *testing.T) {
+	ae := AssertionError{Assertion: "AssertURL", Subject: "url", Matcher: MatchContains, Expected: "/home", Actual: "/login"}
+
+	tests := []struct {
+		mode         AssertMode
+		wantErr      bool
+		wantFailures int
+	}{
+		{AssertHard, true, 0},
+		{AssertSoft, false, 1},
+	}
+
+	for _, tt := range tests {
+		sm := &SiteManager{}
+		sm.SetAssertMode(tt.mode)
+
+		err := sm.assertFailed(ae)
+		var got AssertionError
+		if tt.wantErr && (!errors.As(err, &got) || got != ae) {
+			t.Errorf("mode %v: assertFailed() = %v, want %v", tt.mode, err, ae)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("mode %v: assertFailed() = %v, want nil", tt.mode, err)
+		}
+		if len(sm.AssertionFailures()) != tt.wantFailures {
+			t.Errorf("mode %v: %d failures collected, want %d", tt.mode, len(sm.AssertionFailures()), tt.wantFailures)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/domstorage"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"io/ioutil"
+)
+
+// BrowserState is the saved login session: every cookie of the browser and the web storage of the selected origins
+type BrowserState struct {
+	Cookies []Cookie      `json:"cookies"`
+	Origins []OriginState `json:"origins"`
+}
+
+type OriginState struct {
+	Origin         string            `json:"origin"`
+	LocalStorage   map[string]string `json:"localStorage"`
+	SessionStorage map[string]string `json:"sessionStorage"`
+}
+
+func LoadBrowserState(path string) (BrowserState, error) {
+	var state BrowserState
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(content, &state); err != nil {
+		return state, fmt.Errorf("could not parse browser state %s: %v", path, err)
+	}
+
+	return state, nil
+}
+
+func (bs BrowserState) Save(path string) error {
+	content, err := json.MarshalIndent(bs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the file contains session cookies, keep it private
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+// SaveState writes the cookies and the storage of the origins into a json file, an empty origin means the current page's.
+// The storage of an origin can be read only while a page of that origin is open, so call it right after the login.
+func (sm *SiteManager) SaveState(path string, origins []string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		var state BrowserState
+
+		cookies, err := network.GetAllCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range cookies {
+			state.Cookies = append(state.Cookies, cookieFromNetwork(c))
+		}
+
+		for _, origin := range origins {
+			originState := OriginState{Origin: origin}
+			if err := storageItems(LocalStorage, origin, &originState.LocalStorage).Do(ctx); err != nil {
+				return fmt.Errorf("could not read localStorage of %q: %v", origin, err)
+			}
+			if err := storageItems(SessionStorage, origin, &originState.SessionStorage).Do(ctx); err != nil {
+				return fmt.Errorf("could not read sessionStorage of %q: %v", origin, err)
+			}
+			if originState.Origin == "" {
+				if err := chromedp.Evaluate(`window.location.origin`, &originState.Origin).Do(ctx); err != nil {
+					return err
+				}
+			}
+			state.Origins = append(state.Origins, originState)
+		}
+
+		return state.Save(path)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SaveState", []interface{}{path, origins}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// RestoreState sets the cookies and the storage saved by SaveState.
+// To reach the storage of an origin the tab navigates to it, so open the page to test after restoring the state.
+func (sm *SiteManager) RestoreState(path string, timeoutSec int64, handleError bool) error {
+	state, err := LoadBrowserState(path)
+	if err != nil {
+		sm.Error(err, handleError)
+		return err
+	}
+
+	action := state.restoreAction()
+	if sm.activeGroup != "" {
+		sm.addGroupAction("RestoreState", []interface{}{path}, action)
+		return nil
+	}
+
+	err = sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// InitWithState initializes the SiteManager like Init, then restores the browser state saved into the file if the browser started
+func (sm *SiteManager) InitWithState(d chromedp.Device, defTimeoutSec int64, headless bool, ignoreCertErrors bool, statePath string) error {
+	if err := sm.init(d, defTimeoutSec, headless, ignoreCertErrors); err != nil {
+		sm.Error(err, true)
+		return err
+	}
+
+	return sm.RestoreState(statePath, 0, false)
+}
+
+func (bs BrowserState) restoreAction() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, c := range bs.Cookies {
+			if err := c.setAction().Do(ctx); err != nil {
+				return err
+			}
+		}
+
+		for _, originState := range bs.Origins {
+			if len(originState.LocalStorage) == 0 && len(originState.SessionStorage) == 0 {
+				continue
+			}
+
+			if err := chromedp.Navigate(originState.Origin).Do(ctx); err != nil {
+				return err
+			}
+
+			if err := setStorageItems(ctx, LocalStorage, originState.Origin, originState.LocalStorage); err != nil {
+				return err
+			}
+			if err := setStorageItems(ctx, SessionStorage, originState.Origin, originState.SessionStorage); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func setStorageItems(ctx context.Context, storage StorageType, origin string, items map[string]string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	id, err := storageID(ctx, storage, origin)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range items {
+		if err := domstorage.SetDOMStorageItem(id, key, value).Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
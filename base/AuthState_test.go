@@ -0,0 +1,68 @@
+package base
+
+import (
+	"github.com/chromedp/cdproto/network"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBrowserStateSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state := BrowserState{
+		Cookies: []Cookie{
+			{Name: "sid", Value: "secret", Domain: ".example.com", Path: "/", HTTPOnly: true, Secure: true, SameSite: network.CookieSameSiteStrict},
+			{Name: "theme", Value: "dark", Domain: "example.com", Expires: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+		Origins: []OriginState{{
+			Origin:         "https://example.com",
+			LocalStorage:   map[string]string{"token": "abc"},
+			SessionStorage: map[string]string{},
+		}},
+	}
+
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("state file mode = %v, want 0600", mode)
+	}
+
+	loaded, err := LoadBrowserState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Errorf("LoadBrowserState() = %+v, want %+v", loaded, state)
+	}
+}
+
+func TestLoadBrowserStateErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := LoadBrowserState(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("LoadBrowserState() of a missing file error = %v, want not exist", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := ioutil.WriteFile(corrupt, []byte(`{"cookies": [{"name": "sid"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBrowserState(corrupt); err == nil {
+		t.Error("LoadBrowserState() of a corrupt file succeeded, want error")
+	}
+
+	// the state is not restored, the error is returned without a browser
+	sm := newTestManager()
+	if err := sm.RestoreState(corrupt, 0, false); err == nil {
+		t.Error("RestoreState() of a corrupt file succeeded, want error")
+	}
+}
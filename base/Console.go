@@ -0,0 +1,240 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ConsoleMessage struct {
+	// Level is the console method called: log, info, warning, error, debug, assert, ...
+	Level  string
+	Text   string
+	URL    string
+	Line   int64
+	Group  string
+	Step   int
+	Logged time.Time
+}
+
+func (cm ConsoleMessage) String() string {
+	return fmt.Sprintf("console.%s: %s (%s:%d)", cm.Level, cm.Text, cm.URL, cm.Line)
+}
+
+// IsError tells if the message was logged by console.error or a failing console.assert
+func (cm ConsoleMessage) IsError() bool {
+	return cm.Level == string(runtime.APITypeError) || cm.Level == string(runtime.APITypeAssert)
+}
+
+type PageError struct {
+	Message string
+	URL     string
+	Line    int64
+	Column  int64
+	Group   string
+	Step    int
+	Thrown  time.Time
+}
+
+func (pe PageError) String() string {
+	return fmt.Sprintf("uncaught exception: %s (%s:%d:%d)", pe.Message, pe.URL, pe.Line, pe.Column)
+}
+
+type ConsoleErrors struct {
+	Messages []ConsoleMessage
+	Errors   []PageError
+}
+
+func (ce ConsoleErrors) Error() string {
+	var lines []string
+	for _, pe := range ce.Errors {
+		lines = append(lines, pe.String())
+	}
+	for _, cm := range ce.Messages {
+		lines = append(lines, cm.String())
+	}
+
+	return fmt.Sprintf("page reported %d error(s):\n%s", len(lines), strings.Join(lines, "\n"))
+}
+
+// consoleBuffer keeps the messages and exceptions, tagged by the group step running when they arrived.
+// Outside of GroupProcess the step is -1.
+type consoleBuffer struct {
+	mu       sync.Mutex
+	group    string
+	step     int
+	messages []ConsoleMessage
+	errors   []PageError
+}
+
+func newConsoleBuffer() *consoleBuffer {
+	return &consoleBuffer{step: -1}
+}
+
+func (cb *consoleBuffer) setStep(group string, step int) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.group = group
+	cb.step = step
+}
+
+func (cb *consoleBuffer) addMessage(cm ConsoleMessage) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cm.Group, cm.Step = cb.group, cb.step
+	cb.messages = append(cb.messages, cm)
+}
+
+func (cb *consoleBuffer) addError(pe PageError) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	pe.Group, pe.Step = cb.group, cb.step
+	cb.errors = append(cb.errors, pe)
+}
+
+type consoleMark struct {
+	messages int
+	errors   int
+}
+
+func (cb *consoleBuffer) mark() consoleMark {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return consoleMark{len(cb.messages), len(cb.errors)}
+}
+
+// since returns the messages and exceptions arrived after the mark was taken
+func (cb *consoleBuffer) since(m consoleMark) ([]ConsoleMessage, []PageError) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	var messages []ConsoleMessage
+	if m.messages <= len(cb.messages) {
+		messages = append(messages, cb.messages[m.messages:]...)
+	}
+
+	var errors []PageError
+	if m.errors <= len(cb.errors) {
+		errors = append(errors, cb.errors[m.errors:]...)
+	}
+
+	return messages, errors
+}
+
+// of returns the messages and exceptions arrived while the step of the group was running, in every run of the group
+func (cb *consoleBuffer) of(group string, step int) ([]ConsoleMessage, []PageError) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	var messages []ConsoleMessage
+	for _, cm := range cb.messages {
+		if cm.Group == group && cm.Step == step {
+			messages = append(messages, cm)
+		}
+	}
+
+	var errors []PageError
+	for _, pe := range cb.errors {
+		if pe.Group == group && pe.Step == step {
+			errors = append(errors, pe)
+		}
+	}
+
+	return messages, errors
+}
+
+// ConsoleMessages returns every message logged by the page through the console api
+func (sm SiteManager) ConsoleMessages() []ConsoleMessage {
+	sm.console.mu.Lock()
+	defer sm.console.mu.Unlock()
+
+	return append([]ConsoleMessage(nil), sm.console.messages...)
+}
+
+// PageErrors returns the uncaught exceptions thrown on the page
+func (sm SiteManager) PageErrors() []PageError {
+	sm.console.mu.Lock()
+	defer sm.console.mu.Unlock()
+
+	return append([]PageError(nil), sm.console.errors...)
+}
+
+// StepConsole returns the messages and exceptions arrived while the step of the group was running, in every run of the group
+func (sm SiteManager) StepConsole(group string, step int) ([]ConsoleMessage, []PageError) {
+	return sm.console.of(group, step)
+}
+
+func (sm *SiteManager) ClearConsole() {
+	sm.console.mu.Lock()
+	defer sm.console.mu.Unlock()
+
+	sm.console.messages = nil
+	sm.console.errors = nil
+}
+
+// SetFailOnConsoleError makes the group step fail when the page logs an error or throws an uncaught exception during it
+func (sm *SiteManager) SetFailOnConsoleError(fail bool) {
+	sm.failOnConsoleError = fail
+}
+
+func (sm *SiteManager) onConsoleEvent(ctx context.Context, ev interface{}) {
+	switch e := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		cm := ConsoleMessage{
+			Level:  string(e.Type),
+			Text:   consoleText(e.Args),
+			Logged: time.Now(),
+		}
+		if e.StackTrace != nil && len(e.StackTrace.CallFrames) > 0 {
+			cm.URL = e.StackTrace.CallFrames[0].URL
+			cm.Line = e.StackTrace.CallFrames[0].LineNumber + 1
+		}
+		sm.console.addMessage(cm)
+
+	case *runtime.EventExceptionThrown:
+		details := e.ExceptionDetails
+		pe := PageError{
+			Message: details.Text,
+			URL:     details.URL,
+			Line:    details.LineNumber + 1,
+			Column:  details.ColumnNumber + 1,
+			Thrown:  time.Now(),
+		}
+		if details.Exception != nil && details.Exception.Description != "" {
+			pe.Message = details.Exception.Description
+		}
+		sm.console.addError(pe)
+	}
+}
+
+// consoleText joins the arguments of the console call like the devtools console shows them
+func consoleText(args []*runtime.RemoteObject) string {
+	var parts []string
+	for _, arg := range args {
+		switch {
+		case len(arg.Value) > 0:
+			var s string
+			if err := json.Unmarshal(arg.Value, &s); err == nil {
+				parts = append(parts, s)
+			} else {
+				parts = append(parts, string(arg.Value))
+			}
+		case arg.UnserializableValue != "":
+			parts = append(parts, string(arg.UnserializableValue))
+		case arg.Description != "":
+			parts = append(parts, arg.Description)
+		default:
+			parts = append(parts, string(arg.Type))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
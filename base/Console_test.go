@@ -0,0 +1,138 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"testing"
+)
+
+func TestConsoleBufferSteps(t *testing.T) {
+	sm := newTestManager()
+
+	sm.console.addMessage(ConsoleMessage{Level: "log", Text: "before"})
+	sm.console.setStep("checkout", 0)
+	sm.console.addMessage(ConsoleMessage{Level: "log", Text: "first"})
+	mark := sm.console.mark()
+	sm.console.setStep("checkout", 1)
+	sm.console.addMessage(ConsoleMessage{Level: "error", Text: "second"})
+	sm.console.addError(PageError{Message: "thrown"})
+	sm.console.setStep("", -1)
+
+	messages, errs := sm.StepConsole("checkout", 1)
+	if len(messages) != 1 || messages[0].Text != "second" || len(errs) != 1 || errs[0].Step != 1 {
+		t.Errorf("StepConsole(checkout, 1) = %v, %v", messages, errs)
+	}
+	if messages, _ := sm.StepConsole("", -1); len(messages) != 1 || messages[0].Text != "before" {
+		t.Errorf("messages outside of the groups = %v", messages)
+	}
+	if messages, errs := sm.console.since(mark); len(messages) != 1 || len(errs) != 1 {
+		t.Errorf("since() = %v, %v, want the messages of step 1", messages, errs)
+	}
+
+	sm.ClearConsole()
+	if len(sm.ConsoleMessages()) != 0 || len(sm.PageErrors()) != 0 {
+		t.Errorf("ClearConsole() kept %v, %v", sm.ConsoleMessages(), sm.PageErrors())
+	}
+	// a mark taken before the clear finds nothing
+	if messages, errs := sm.console.since(mark); messages != nil || errs != nil {
+		t.Errorf("since() after ClearConsole() = %v, %v", messages, errs)
+	}
+}
+
+func TestOnConsoleEvent(t *testing.T) {
+	sm := newTestManager()
+
+	sm.onConsoleEvent(context.Background(), &runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeWarning,
+		Args: []*runtime.RemoteObject{
+			{Type: runtime.TypeString, Value: []byte(`"total:"`)},
+			{Type: runtime.TypeNumber, Value: []byte(`42`)},
+			{Type: runtime.TypeNumber, UnserializableValue: "NaN"},
+			{Type: runtime.TypeObject, Description: "Object"},
+			{Type: runtime.TypeUndefined},
+		},
+		StackTrace: &runtime.StackTrace{CallFrames: []*runtime.CallFrame{{URL: "https://example.com/app.js", LineNumber: 9}}},
+	})
+	sm.onConsoleEvent(context.Background(), &runtime.EventExceptionThrown{
+		ExceptionDetails: &runtime.ExceptionDetails{
+			Text:         "Uncaught",
+			URL:          "https://example.com/app.js",
+			LineNumber:   4,
+			ColumnNumber: 2,
+			Exception:    &runtime.RemoteObject{Description: "TypeError: x is undefined"},
+		},
+	})
+
+	messages := sm.ConsoleMessages()
+	if len(messages) != 1 {
+		t.Fatalf("%d messages, want 1", len(messages))
+	}
+	if cm := messages[0]; cm.Level != "warning" || cm.Text != "total: 42 NaN Object undefined" || cm.Line != 10 || cm.Step != -1 || cm.IsError() {
+		t.Errorf("message = %+v", cm)
+	}
+
+	errs := sm.PageErrors()
+	if len(errs) != 1 || errs[0].Message != "TypeError: x is undefined" || errs[0].Line != 5 || errs[0].Column != 3 {
+		t.Errorf("page errors = %+v", errs)
+	}
+}
+
+func TestGroupFailOnConsoleError(t *testing.T) {
+	tests := []struct {
+		name       string
+		fail       bool
+		wantStatus []StepStatus
+	}{
+		{"reported", false, []StepStatus{StepPassed, StepPassed, StepPassed}},
+		{"failing", true, []StepStatus{StepPassed, StepFailed, StepSkipped}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestManager()
+			sm.ctx = browserlessContext(t)
+			sm.fixActions = nil
+			sm.SetFailOnConsoleError(tt.fail)
+
+			log := func(level runtime.APIType, text string) chromedp.Action {
+				return chromedp.ActionFunc(func(ctx context.Context) error {
+					sm.onConsoleEvent(ctx, &runtime.EventConsoleAPICalled{
+						Type: level,
+						Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"` + text + `"`)}},
+					})
+					return nil
+				})
+			}
+			actions := []groupAction{
+				{name: "info", action: log(runtime.APITypeLog, "loaded")},
+				{name: "error", action: log(runtime.APITypeError, "broken")},
+				{name: "after", action: log(runtime.APITypeLog, "after")},
+			}
+
+			result := sm.runGroup("console", actions, 0)
+			for i, step := range result.Steps {
+				if step.Status != tt.wantStatus[i] {
+					t.Errorf("step %d is %s, want %s", i, step.Status, tt.wantStatus[i])
+				}
+			}
+
+			var ce ConsoleErrors
+			if errors.As(result.Err, &ce) != tt.fail {
+				t.Errorf("result error = %v, want console errors %v", result.Err, tt.fail)
+			}
+			if tt.fail && (len(ce.Messages) != 1 || ce.Messages[0].Text != "broken") {
+				t.Errorf("console errors = %+v", ce)
+			}
+
+			// the messages are in the result of their step, and tagged by it
+			if step := result.Steps[1]; len(step.ConsoleMessages) != 1 || step.ConsoleMessages[0].Text != "broken" {
+				t.Errorf("console messages of step 1 = %v", step.ConsoleMessages)
+			}
+			if messages, _ := sm.StepConsole("console", 1); len(messages) != 1 {
+				t.Errorf("StepConsole(console, 1) = %v", messages)
+			}
+		})
+	}
+}
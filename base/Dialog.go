@@ -0,0 +1,246 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"sync"
+	"time"
+)
+
+type DialogPolicy int
+
+const (
+	// DialogAccept accepts every dialog, prompts are answered by their default text, it is the default policy
+	DialogAccept DialogPolicy = iota
+	// DialogDismiss cancels every dialog
+	DialogDismiss
+	// DialogAnswer accepts every dialog, prompts are answered by the text of the policy
+	DialogAnswer
+	// DialogQueue leaves the dialogs open until HandleDialog closes them. The action opening the dialog ends when the dialog opens,
+	// the rest of it is not run, so the next actions (also in a group) can get the dialog by WaitDialog and close it by HandleDialog
+	DialogQueue
+)
+
+type DialogInfo struct {
+	// Type is alert, confirm, prompt or beforeunload
+	Type          string
+	Message       string
+	DefaultPrompt string
+	// URL is the url of the frame opening the dialog
+	URL    string
+	Opened time.Time
+	// Handled tells if the dialog is closed already, Accepted and Input are the answer it was closed by
+	Handled  bool
+	Accepted bool
+	Input    string
+}
+
+func (di DialogInfo) String() string {
+	return fmt.Sprintf("%s dialog: %s (%s)", di.Type, di.Message, di.URL)
+}
+
+// dialogQueue keeps the dialogs opened in the tabs of the SiteManager, the events of the tabs update it
+type dialogQueue struct {
+	mu      sync.Mutex
+	policy  DialogPolicy
+	answer  string
+	dialogs []DialogInfo
+	// waited is the count of dialogs returned by WaitDialog
+	waited int
+	// changed is closed and replaced when a dialog opens, held when a dialog opens and stays open by the DialogQueue policy
+	changed chan struct{}
+	held    chan struct{}
+}
+
+func newDialogQueue() *dialogQueue {
+	return &dialogQueue{changed: make(chan struct{}), held: make(chan struct{})}
+}
+
+// open records the dialog and returns the answer of the policy, handle is false if it has to stay open
+func (dq *dialogQueue) open(di DialogInfo) (accept bool, text string, handle bool) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	dq.dialogs = append(dq.dialogs, di)
+	close(dq.changed)
+	dq.changed = make(chan struct{})
+
+	switch dq.policy {
+	case DialogAccept:
+		return true, di.DefaultPrompt, true
+	case DialogDismiss:
+		return false, "", true
+	case DialogAnswer:
+		return true, dq.answer, true
+	}
+
+	close(dq.held)
+	dq.held = make(chan struct{})
+
+	return false, "", false
+}
+
+// heldChan returns the channel closed when the next dialog stays open
+func (dq *dialogQueue) heldChan() <-chan struct{} {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	return dq.held
+}
+
+// closed marks the oldest open dialog as handled
+func (dq *dialogQueue) closed(accepted bool, input string) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	for i := range dq.dialogs {
+		if !dq.dialogs[i].Handled {
+			dq.dialogs[i].Handled = true
+			dq.dialogs[i].Accepted = accepted
+			dq.dialogs[i].Input = input
+			return
+		}
+	}
+}
+
+// next returns the oldest dialog not returned yet, or the channel closed when a dialog opens
+func (dq *dialogQueue) next() (DialogInfo, bool, <-chan struct{}) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	if dq.waited == len(dq.dialogs) {
+		return DialogInfo{}, false, dq.changed
+	}
+
+	di := dq.dialogs[dq.waited]
+	dq.waited++
+
+	return di, true, nil
+}
+
+func (dq *dialogQueue) clear() {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	dq.policy = DialogAccept
+	dq.answer = ""
+	dq.dialogs = nil
+	dq.waited = 0
+}
+
+// SetDialogPolicy sets how the alert, confirm, prompt and beforeunload dialogs of the page are closed,
+// the answer is the text of the prompts for DialogAnswer. The dialogs are accepted until it is called.
+func (sm *SiteManager) SetDialogPolicy(policy DialogPolicy, answer string) {
+	sm.dialogs.mu.Lock()
+	defer sm.dialogs.mu.Unlock()
+
+	sm.dialogs.policy = policy
+	sm.dialogs.answer = answer
+}
+
+// Dialogs returns every dialog opened by the page, with the answer they were closed by
+func (sm SiteManager) Dialogs() []DialogInfo {
+	sm.dialogs.mu.Lock()
+	defer sm.dialogs.mu.Unlock()
+
+	return append([]DialogInfo(nil), sm.dialogs.dialogs...)
+}
+
+// WaitDialog waits for a dialog opened by the page and sets it into the pointer, with every policy.
+// The dialogs are returned in the order they opened, one dialog once, so the dialog opened before calling it is returned too.
+func (sm *SiteManager) WaitDialog(into *DialogInfo, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		for {
+			di, ok, changed := sm.dialogs.next()
+			if ok {
+				*into = di
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("no dialog opened: %v", ctx.Err())
+			case <-changed:
+			}
+		}
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("WaitDialog", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// HandleDialog closes the dialog open in the active tab by accepting or cancelling it, the promptText is the answer of a prompt
+func (sm *SiteManager) HandleDialog(accept bool, promptText string, timeoutSec int64, handleError bool) error {
+	action := page.HandleJavaScriptDialog(accept).WithPromptText(promptText)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("HandleDialog", []interface{}{accept, promptText}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// run runs the actions like chromedp.Run, but if a dialog opens and stays open by the DialogQueue policy,
+// the action waiting for the dialog to close is abandoned and run returns, so the dialog can be handled by the next actions
+func (sm *SiteManager) run(ctx context.Context, actions ...chromedp.Action) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	held := sm.dialogs.heldChan()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-held:
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	err := chromedp.Run(ctx, actions...)
+	if err != nil {
+		select {
+		case <-held:
+			return nil
+		default:
+		}
+	}
+
+	return err
+}
+
+func (sm *SiteManager) onDialogEvent(ctx context.Context, ev interface{}) {
+	switch e := ev.(type) {
+	case *page.EventJavascriptDialogOpening:
+		accept, text, handle := sm.dialogs.open(DialogInfo{
+			Type:          string(e.Type),
+			Message:       e.Message,
+			DefaultPrompt: e.DefaultPrompt,
+			URL:           e.URL,
+			Opened:        time.Now(),
+		})
+		if !handle {
+			return
+		}
+
+		go func() {
+			if err := execute(ctx, page.HandleJavaScriptDialog(accept).WithPromptText(text)); err != nil {
+				sm.logf("could not close %s dialog: %v", e.Type, err)
+			}
+		}()
+
+	case *page.EventJavascriptDialogClosed:
+		sm.dialogs.closed(e.Result, e.UserInput)
+	}
+}
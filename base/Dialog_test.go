@@ -0,0 +1,97 @@
+package base
+
+import "testing"
+
+func TestDialogQueueOpen(t *testing.T) {
+	prompt := DialogInfo{Type: "prompt", Message: "name?", DefaultPrompt: "guest"}
+
+	tests := []struct {
+		name       string
+		policy     DialogPolicy
+		answer     string
+		wantAccept bool
+		wantText   string
+		wantHandle bool
+	}{
+		{"default", DialogPolicy(0), "", true, "guest", true},
+		{"accept", DialogAccept, "", true, "guest", true},
+		{"dismiss", DialogDismiss, "", false, "", true},
+		{"answer", DialogAnswer, "admin", true, "admin", true},
+		{"queue", DialogQueue, "", false, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dq := newDialogQueue()
+			dq.policy, dq.answer = tt.policy, tt.answer
+
+			accept, text, handle := dq.open(prompt)
+			if accept != tt.wantAccept || text != tt.wantText || handle != tt.wantHandle {
+				t.Errorf("open() = %v, %q, %v, want %v, %q, %v", accept, text, handle, tt.wantAccept, tt.wantText, tt.wantHandle)
+			}
+		})
+	}
+}
+
+func TestDialogQueueNext(t *testing.T) {
+	dq := newDialogQueue()
+
+	_, ok, changed := dq.next()
+	if ok {
+		t.Fatal("next() returned a dialog of an empty queue")
+	}
+
+	dq.open(DialogInfo{Type: "alert", Message: "first"})
+	select {
+	case <-changed:
+	default:
+		t.Fatal("opening a dialog did not close the changed channel")
+	}
+	dq.open(DialogInfo{Type: "confirm", Message: "second"})
+	dq.closed(true, "")
+
+	for _, want := range []string{"first", "second"} {
+		di, ok, _ := dq.next()
+		if !ok || di.Message != want {
+			t.Fatalf("next() = %v, %v, want %s", di, ok, want)
+		}
+	}
+	if _, ok, _ := dq.next(); ok {
+		t.Error("next() returned a dialog twice")
+	}
+
+	dialogs := dq.dialogs
+	if !dialogs[0].Handled || !dialogs[0].Accepted || dialogs[1].Handled {
+		t.Errorf("closed() marked %+v, want the first dialog accepted only", dialogs)
+	}
+
+	dq.policy = DialogQueue
+	dq.clear()
+	if dq.policy != DialogAccept || len(dq.dialogs) != 0 {
+		t.Errorf("clear() left policy %v and %d dialogs", dq.policy, len(dq.dialogs))
+	}
+}
+
+func TestDialogQueueHeld(t *testing.T) {
+	dq := newDialogQueue()
+
+	held := dq.heldChan()
+	dq.open(DialogInfo{Type: "alert"})
+	select {
+	case <-held:
+		t.Fatal("an accepted dialog closed the held channel")
+	default:
+	}
+
+	// the action opening a queued dialog is ended by the held channel
+	dq.policy = DialogQueue
+	dq.open(DialogInfo{Type: "confirm"})
+	select {
+	case <-held:
+	default:
+		t.Fatal("a queued dialog did not close the held channel")
+	}
+	if dq.heldChan() == held {
+		t.Error("the held channel is not replaced")
+	}
+}
@@ -0,0 +1,359 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Download struct {
+	URL string
+	// SuggestedName is the name the site suggested for the file, Name is the name chrome saved it under,
+	// with a " (1)" like suffix if a file of the suggested name existed already
+	SuggestedName string
+	Name          string
+	Path          string
+	Size          int64
+	MIME          string
+}
+
+// downloadQueue keeps the downloads started in the tab, and the files of the download directory returned already
+type downloadQueue struct {
+	mu  sync.Mutex
+	dir string
+	// began are the urls of the downloads not returned by next yet, waiting are the ones returned and waited for by WaitDownload
+	began   []string
+	waiting map[string]int
+	claimed map[string]bool
+	// changed is closed and replaced when a download begins
+	changed chan struct{}
+}
+
+func newDownloadQueue() *downloadQueue {
+	return &downloadQueue{waiting: make(map[string]int), changed: make(chan struct{})}
+}
+
+func (dq *downloadQueue) setDir(dir string, existing []string) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	dq.dir = dir
+	dq.claimed = make(map[string]bool)
+	for _, name := range existing {
+		dq.claimed[name] = true
+	}
+}
+
+func (dq *downloadQueue) directory() string {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	return dq.dir
+}
+
+func (dq *downloadQueue) begin(url string) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	dq.began = append(dq.began, url)
+	close(dq.changed)
+	dq.changed = make(chan struct{})
+}
+
+// next returns the oldest download begun and not returned yet, or the channel closed when a download begins
+func (dq *downloadQueue) next() (string, bool, <-chan struct{}) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	if len(dq.began) == 0 {
+		return "", false, dq.changed
+	}
+
+	url := dq.began[0]
+	dq.began = dq.began[1:]
+	dq.waiting[url]++
+
+	return url, true, nil
+}
+
+// finished returns the file of the download of the url when it is complete: not returned before and not growing since the previous call,
+// sizes keeps the sizes between the calls. The file named like the url is taken, a file of another name only if no download
+// in progress is named like it and no file is named like the url, so downloads of such names are paired in the order they complete.
+func (dq *downloadQueue) finished(url string, sizes map[string]int64) (string, bool, error) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	files, err := ioutil.ReadDir(dq.dir)
+	if err != nil {
+		return "", false, err
+	}
+
+	own := urlFileName(url)
+	others := make(map[string]bool)
+	for _, u := range dq.began {
+		others[urlFileName(u)] = true
+	}
+	for u, n := range dq.waiting {
+		if n > 0 && u != url {
+			others[urlFileName(u)] = true
+		}
+	}
+
+	ownSeen := false
+	var unnamed string
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || dq.claimed[name] || strings.HasSuffix(name, ".crdownload") {
+			continue
+		}
+
+		suggested := suggestedName(name)
+		if suggested == own {
+			ownSeen = true
+		}
+
+		previous, seen := sizes[name]
+		sizes[name] = fi.Size()
+		if !seen || previous != fi.Size() {
+			continue
+		}
+
+		if suggested == own {
+			return dq.claim(url, name), true, nil
+		}
+		if unnamed == "" && !others[suggested] {
+			unnamed = name
+		}
+	}
+
+	if unnamed != "" && !ownSeen {
+		return dq.claim(url, unnamed), true, nil
+	}
+
+	return "", false, nil
+}
+
+func (dq *downloadQueue) claim(url string, name string) string {
+	dq.claimed[name] = true
+	if dq.waiting[url]--; dq.waiting[url] <= 0 {
+		delete(dq.waiting, url)
+	}
+
+	return name
+}
+
+// done forgets the download of the url waited for, if WaitDownload gives up on it
+func (dq *downloadQueue) done(url string) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	if dq.waiting[url]--; dq.waiting[url] <= 0 {
+		delete(dq.waiting, url)
+	}
+}
+
+// clear forgets the downloads and the download directory of the previous user
+func (dq *downloadQueue) clear() {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	dq.dir = ""
+	dq.began = nil
+	dq.waiting = make(map[string]int)
+	dq.claimed = nil
+}
+
+// urlFileName returns the last segment of the path of the url, the name chrome saves the download under if the site suggests none
+func urlFileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+
+	return name
+}
+
+var uniqueSuffix = regexp.MustCompile(` \(\d+\)$`)
+
+// suggestedName removes the " (1)" like suffix chrome adds to the name of the file if one of the name exists already
+func suggestedName(name string) string {
+	ext := filepath.Ext(name)
+
+	return uniqueSuffix.ReplaceAllString(strings.TrimSuffix(name, ext), "") + ext
+}
+
+// SetDownloadDir makes the browser save the downloads of the tab into the directory, it is created if it does not exist.
+// The files already in the directory are not returned by WaitDownload. With a browser started by NewRemote the directory has to be shared with the machine of the browser on the same path.
+func (sm *SiteManager) SetDownloadDir(dir string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(abs, 0755); err != nil {
+			return err
+		}
+
+		files, err := ioutil.ReadDir(abs)
+		if err != nil {
+			return err
+		}
+		var existing []string
+		for _, fi := range files {
+			existing = append(existing, fi.Name())
+		}
+		sm.downloads.setDir(abs, existing)
+		sm.listen("download", sm.onDownloadEvent)
+
+		return page.SetDownloadBehavior(page.SetDownloadBehaviorBehaviorAllow).WithDownloadPath(abs).Do(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SetDownloadDir", []interface{}{dir}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// WaitDownload waits for a download of the page to complete and sets it into the pointer.
+// The downloads are returned in the order they began, one download once, so the download begun before calling it is returned too.
+// The file of a download is found by the last segment of its url, downloads saved under another name
+// (a name sent by the site, a blob url, ...) are paired with their files in the order they complete, so start those one at a time.
+func (sm *SiteManager) WaitDownload(into *Download, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		dir := sm.downloads.directory()
+		if dir == "" {
+			return errors.New("no download directory, call SetDownloadDir first")
+		}
+
+		var url string
+		for {
+			next, ok, changed := sm.downloads.next()
+			if ok {
+				url = next
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("no download began: %v", ctx.Err())
+			case <-changed:
+			}
+		}
+
+		// chrome does not report the end of the download in this protocol version, the file is done when it stops growing
+		sizes := make(map[string]int64)
+		for {
+			name, ok, err := sm.downloads.finished(url, sizes)
+			if err != nil {
+				sm.downloads.done(url)
+				return err
+			}
+			if ok {
+				d, err := downloadOf(dir, name)
+				if err != nil {
+					return err
+				}
+				d.URL = url
+				*into = d
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				sm.downloads.done(url)
+				return fmt.Errorf("download of %s did not complete: %v", url, ctx.Err())
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("WaitDownload", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// AssertDownload checks the content of the downloaded file, the download is read when the action runs, so it can be filled by WaitDownload of the same group
+func (sm *SiteManager) AssertDownload(download *Download, matcher Matcher, expected string, timeoutSec int64, handleError bool) error {
+	var actual string
+	fetch := chromedp.ActionFunc(func(ctx context.Context) error {
+		if download.Path == "" {
+			return errors.New("no download to assert on")
+		}
+
+		content, err := ioutil.ReadFile(download.Path)
+		if err != nil {
+			return err
+		}
+		actual = string(content)
+
+		return nil
+	})
+	action := sm.assertAction("AssertDownload", "download", matcher, expected, &actual, fetch)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("AssertDownload", []interface{}{matcher, expected}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) onDownloadEvent(ctx context.Context, ev interface{}) {
+	if e, ok := ev.(*page.EventDownloadWillBegin); ok {
+		sm.downloads.begin(e.URL)
+	}
+}
+
+// downloadOf describes the file, the mime type comes from the extension or the content if the extension is unknown
+func downloadOf(dir string, name string) (Download, error) {
+	path := filepath.Join(dir, name)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Download{}, err
+	}
+
+	d := Download{SuggestedName: suggestedName(name), Name: name, Path: path, Size: fi.Size(), MIME: mime.TypeByExtension(filepath.Ext(name))}
+	if d.MIME == "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return Download{}, err
+		}
+		defer f.Close()
+
+		head := make([]byte, 512)
+		n, _ := f.Read(head)
+		d.MIME = http.DetectContentType(head[:n])
+	}
+
+	return d, nil
+}
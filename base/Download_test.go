@@ -0,0 +1,193 @@
+package base
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDownloadQueueFinished(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("old.txt", "downloaded before")
+	dq := newDownloadQueue()
+	dq.setDir(dir, []string{"old.txt"})
+
+	write("report.pdf.crdownload", "partial")
+	write("report.csv", "a,b")
+	sizes := make(map[string]int64)
+
+	const url = "https://example.com/export/report.csv"
+	dq.begin(url)
+	dq.next()
+
+	// a file is finished when its size did not change since the previous call
+	if name, ok, err := dq.finished(url, sizes); err != nil || ok {
+		t.Fatalf("finished() = %q, %v, %v at the first look, want nothing", name, ok, err)
+	}
+	write("report.csv", "a,b\n1,2")
+	if name, ok, err := dq.finished(url, sizes); err != nil || ok {
+		t.Fatalf("finished() = %q, %v, %v while the file grows, want nothing", name, ok, err)
+	}
+
+	name, ok, err := dq.finished(url, sizes)
+	if err != nil || !ok || name != "report.csv" {
+		t.Fatalf("finished() = %q, %v, %v, want report.csv", name, ok, err)
+	}
+	if name, ok, _ := dq.finished(url, sizes); ok {
+		t.Errorf("finished() returned %q again", name)
+	}
+}
+
+func TestDownloadQueueOverlapping(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dq := newDownloadQueue()
+	dq.setDir(dir, []string{"data.json"})
+	for _, url := range []string{"https://example.com/big.zip", "https://example.com/data.json?v=2", "blob:https://example.com/1f2e"} {
+		dq.begin(url)
+		dq.next()
+	}
+
+	// the later downloads complete first, the existing data.json made chrome name the new one uniquely
+	write("data (1).json", `{}`)
+	write("export.csv", "a,b")
+	write("big.zip", "PK")
+	sizes := make(map[string]int64)
+	if name, ok, _ := dq.finished("https://example.com/big.zip", sizes); ok {
+		t.Fatalf("finished() = %q at the first look, want nothing", name)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/big.zip", "big.zip"},
+		{"https://example.com/data.json?v=2", "data (1).json"},
+		// the blob is not named by its url, it gets the file no other download is named like
+		{"blob:https://example.com/1f2e", "export.csv"},
+	}
+
+	for _, tt := range tests {
+		if name, ok, err := dq.finished(tt.url, sizes); err != nil || !ok || name != tt.want {
+			t.Errorf("finished(%s) = %q, %v, %v, want %q", tt.url, name, ok, err, tt.want)
+		}
+	}
+	if len(dq.waiting) != 0 {
+		t.Errorf("downloads still waited for: %v", dq.waiting)
+	}
+}
+
+func TestSuggestedName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report.csv", "report.csv"},
+		{"report (1).csv", "report.csv"},
+		{"report (12).csv", "report.csv"},
+		{"report(1).csv", "report(1).csv"},
+		{"notes (draft).txt", "notes (draft).txt"},
+		{"README (2)", "README"},
+	}
+
+	for _, tt := range tests {
+		if got := suggestedName(tt.name); got != tt.want {
+			t.Errorf("suggestedName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadQueueNext(t *testing.T) {
+	dq := newDownloadQueue()
+
+	_, ok, changed := dq.next()
+	if ok {
+		t.Fatal("next() returned a download of an empty queue")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		// the download events arrive on the goroutines of chromedp
+		go func(i int) {
+			defer wg.Done()
+			dq.begin(fmt.Sprintf("https://example.com/%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	select {
+	case <-changed:
+	default:
+		t.Fatal("a begun download did not close the changed channel")
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		url, ok, _ := dq.next()
+		if !ok || seen[url] {
+			t.Fatalf("next() = %q, %v", url, ok)
+		}
+		seen[url] = true
+	}
+
+	dq.setDir(t.TempDir(), []string{"old.txt"})
+	dq.begin("https://example.com/left")
+	dq.clear()
+	if _, ok, _ := dq.next(); ok {
+		t.Error("next() after clear() returned a download")
+	}
+	// the next user of a pooled SiteManager sets its own directory
+	if dq.directory() != "" || dq.claimed != nil || len(dq.waiting) != 0 {
+		t.Errorf("the queue kept the state of the previous user: dir %q, claimed %v, waiting %v", dq.directory(), dq.claimed, dq.waiting)
+	}
+}
+
+func TestDownloadOf(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"data.json": `{"a": 1}`,
+		"page":      "<html><body>hi</body></html>",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		wantMIME string
+		wantSize int64
+	}{
+		{"data.json", "application/json", 8},
+		// without extension the content tells the type
+		{"page", "text/html; charset=utf-8", 28},
+	}
+
+	for _, tt := range tests {
+		d, err := downloadOf(dir, tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Name != tt.name || d.SuggestedName != tt.name || d.Path != filepath.Join(dir, tt.name) || d.MIME != tt.wantMIME || d.Size != tt.wantSize {
+			t.Errorf("downloadOf(%s) = %+v", tt.name, d)
+		}
+	}
+
+	if _, err := downloadOf(dir, "missing"); err == nil {
+		t.Error("downloadOf() of a missing file succeeded, want error")
+	}
+}
@@ -195,6 +195,11 @@ func Button(tagPos int) *Element {
 	return e.ByTag("button", tagPos)
 }
 
+func Iframe(tagPos int) *Element {
+	var e Element
+	return e.ByTag("iframe", tagPos)
+}
+
 func Input(tagPos int) *Element {
 	var e Element
 	return e.ByTag("input", tagPos)
@@ -0,0 +1,70 @@
+package base
+
+import (
+	"context"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+)
+
+type eventHandler func(ctx context.Context, ev interface{})
+
+// listen registers the handler once by its name on the active tab of the SiteManager, on every tab it switches to,
+// and on the tabs opened by the page from then on.
+// The handlers are called synchronously while the events are dispatched, so they must not block,
+// any action they send to the browser has to run in a new goroutine by execute.
+func (sm *SiteManager) listen(name string, handler eventHandler) {
+	sm.tabs.mu.Lock()
+	defer sm.tabs.mu.Unlock()
+
+	if sm.handlers == nil {
+		sm.handlers = make(map[string]eventHandler)
+	}
+
+	if _, ok := sm.handlers[name]; !ok {
+		sm.handlers[name] = handler
+	}
+
+	sm.listenTab(sm.tabs.contexts[sm.tabs.active])
+}
+
+// listenTab registers the handlers not listening on the tab yet, the mutex of the tabs is held by the caller
+func (sm *SiteManager) listenTab(tc *tabContext) {
+	for name, handler := range sm.handlers {
+		if tc.handlers[name] {
+			continue
+		}
+
+		tc.handlers[name] = true
+		// the tab context is not limited by the session timeout, the handlers live as long as the tab
+		listenTarget(tc.ctx, handler)
+	}
+}
+
+func listenTarget(ctx context.Context, handler eventHandler) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		handler(ctx, ev)
+	})
+}
+
+// execute runs the action on the tab of the context outside of chromedp.Run, for the event handlers
+func execute(ctx context.Context, action chromedp.Action) error {
+	c := chromedp.FromContext(ctx)
+	if c == nil {
+		return chromedp.ErrInvalidContext
+	}
+	if c.Target == nil {
+		return chromedp.ErrInvalidTarget
+	}
+
+	return action.Do(cdp.WithExecutor(ctx, c.Target))
+}
+
+// browserContext returns the context executing the commands on the browser of the tab instead of the tab
+func browserContext(ctx context.Context) (context.Context, error) {
+	c := chromedp.FromContext(ctx)
+	if c == nil || c.Browser == nil {
+		return nil, chromedp.ErrInvalidContext
+	}
+
+	return cdp.WithExecutor(ctx, c.Browser), nil
+}
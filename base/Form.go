@@ -0,0 +1,254 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"sort"
+)
+
+type OptionBy int
+
+const (
+	// OptionByValue chooses the options by their value attribute
+	OptionByValue OptionBy = iota
+	// OptionByLabel chooses the options by their label or visible text
+	OptionByLabel
+	// OptionByIndex chooses the options by their position in the select from zero, the choices are the numbers as strings ("0", "2")
+	OptionByIndex
+)
+
+func (by OptionBy) String() string {
+	switch by {
+	case OptionByLabel:
+		return "label"
+	case OptionByIndex:
+		return "index"
+	}
+
+	return "value"
+}
+
+// selectOptionsJS selects the options of the choices only, then fires the events, it returns the indexes selected or the error
+const selectOptionsJS = `function(by, choices) {
+	if (this.tagName !== 'SELECT') {
+		return {error: 'the element is not a select'};
+	}
+	if (this.disabled) {
+		return {error: 'the select is disabled'};
+	}
+	if (!this.multiple && choices.length !== 1) {
+		return {error: 'the select allows one option only'};
+	}
+
+	var options = Array.prototype.slice.call(this.options);
+	var picked = [];
+	for (var i = 0; i < choices.length; i++) {
+		var choice = choices[i];
+		var option = options.find(function(o) {
+			switch (by) {
+			case 'label':
+				return o.label.trim() === choice.trim() || o.text.trim() === choice.trim();
+			case 'index':
+				return String(o.index) === choice;
+			}
+			return o.value === choice;
+		});
+		if (!option) {
+			return {error: 'no option with ' + by + ' ' + JSON.stringify(choice)};
+		}
+		if (option.disabled) {
+			return {error: 'the option with ' + by + ' ' + JSON.stringify(choice) + ' is disabled'};
+		}
+		picked.push(option.index);
+	}
+
+	options.forEach(function(o) { o.selected = picked.indexOf(o.index) >= 0; });
+	this.dispatchEvent(new Event('input', {bubbles: true}));
+	this.dispatchEvent(new Event('change', {bubbles: true}));
+
+	return {picked: picked};
+}`
+
+const selectedOptionsJS = `function() {
+	return Array.prototype.slice.call(this.selectedOptions).map(function(o) { return o.index; });
+}`
+
+// setCheckedJS clicks the checkbox or radio if it is not in the state yet, the click fires the click, input and change events like a user does
+const setCheckedJS = `function(type, checked) {
+	if (this.tagName !== 'INPUT' || this.type !== type) {
+		return 'the element is not a ' + type + ' input';
+	}
+	if (this.disabled) {
+		return 'the ' + type + ' is disabled';
+	}
+	if (this.checked !== checked) {
+		this.click();
+	}
+
+	return '';
+}`
+
+type selectResult struct {
+	Error  string `json:"error"`
+	Picked []int  `json:"picked"`
+}
+
+// SelectOption selects the options of the choices in the select element and deselects the others, a multi-select takes more choices.
+// The input and change events are fired, then the selected options are checked, the page must not change them.
+func (sm *SiteManager) SelectOption(selector string, by OptionBy, choices []string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return selectOptions(ctx, el, by, choices)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SelectOption", []interface{}{selector, by.String(), choices}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// SetChecked checks or unchecks the checkbox by clicking it if needed, then checks its state
+func (sm *SiteManager) SetChecked(selector string, checked bool, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return setChecked(ctx, el, "checkbox", checked)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SetChecked", []interface{}{selector, checked}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// ChooseRadio checks the radio button by clicking it if needed, then checks its state
+func (sm *SiteManager) ChooseRadio(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return setChecked(ctx, el, "radio", true)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ChooseRadio", []interface{}{selector}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (f *FrameScope) SelectOption(selector string, by OptionBy, choices []string, timeoutSec int64, handleError bool) error {
+	action := f.presentAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return selectOptions(ctx, el, by, choices)
+	})
+
+	return f.do("SelectOption", []interface{}{selector, by.String(), choices}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) SetChecked(selector string, checked bool, timeoutSec int64, handleError bool) error {
+	action := f.presentAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return setChecked(ctx, el, "checkbox", checked)
+	})
+
+	return f.do("SetChecked", []interface{}{selector, checked}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) ChooseRadio(selector string, timeoutSec int64, handleError bool) error {
+	action := f.presentAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return setChecked(ctx, el, "radio", true)
+	})
+
+	return f.do("ChooseRadio", []interface{}{selector}, action, timeoutSec, handleError)
+}
+
+// presentAction waits until the element of the selector is in the frame, the form inputs are often hidden behind a styled label
+func (f *FrameScope) presentAction(selector string, fn func(ctx context.Context, el *runtime.RemoteObject) error) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		el, err := f.waitElement(ctx, selector, `el !== null`, true)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, el)
+	})
+}
+
+// nodeAction waits for the first node of the selector in the tab, then calls the function with its javascript object
+func nodeAction(selector string, fn func(ctx context.Context, el *runtime.RemoteObject) error, options ...chromedp.QueryOption) chromedp.Action {
+	return chromedp.QueryAfter(selector, func(ctx context.Context, nodes ...*cdp.Node) error {
+		if len(nodes) < 1 {
+			return fmt.Errorf("selector %q did not return any nodes", selector)
+		}
+
+		el, err := dom.ResolveNode().WithNodeID(nodes[0].NodeID).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, el)
+	}, options...)
+}
+
+func selectOptions(ctx context.Context, el *runtime.RemoteObject, by OptionBy, choices []string) error {
+	if choices == nil {
+		choices = []string{}
+	}
+
+	var res selectResult
+	if err := callOn(ctx, el, selectOptionsJS, &res, by.String(), choices); err != nil {
+		return err
+	}
+	if res.Error != "" {
+		return fmt.Errorf("could not select %v by %s: %s", choices, by, res.Error)
+	}
+
+	var selected []int
+	if err := callOn(ctx, el, selectedOptionsJS, &selected); err != nil {
+		return err
+	}
+
+	sort.Ints(res.Picked)
+	sort.Ints(selected)
+	if fmt.Sprint(selected) != fmt.Sprint(res.Picked) {
+		return fmt.Errorf("the options %v are selected instead of %v after the change events", selected, res.Picked)
+	}
+
+	return nil
+}
+
+func setChecked(ctx context.Context, el *runtime.RemoteObject, inputType string, checked bool) error {
+	var message string
+	if err := callOn(ctx, el, setCheckedJS, &message, inputType, checked); err != nil {
+		return err
+	}
+	if message != "" {
+		return fmt.Errorf("could not set the %s: %s", inputType, message)
+	}
+
+	var state bool
+	if err := callOn(ctx, el, `function() { return this.checked; }`, &state); err != nil {
+		return err
+	}
+	if state != checked {
+		return fmt.Errorf("the %s is %s after the click", inputType, checkedState(state))
+	}
+
+	return nil
+}
+
+func checkedState(checked bool) string {
+	if checked {
+		return "checked"
+	}
+
+	return "unchecked"
+}
@@ -0,0 +1,88 @@
+package base
+
+import (
+	"github.com/chromedp/cdproto/runtime"
+	"strings"
+	"testing"
+)
+
+// remoteValue is the answer of Runtime.callFunctionOn returning the json value
+func remoteValue(value string) string {
+	return `{"result": {"type": "object", "value": ` + value + `}}`
+}
+
+func TestSelectOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		by       OptionBy
+		choices  []string
+		answers  []string
+		wantArgs string
+		wantErr  string
+	}{
+		{"selected", OptionByLabel, []string{"Red", "Blue"}, []string{remoteValue(`{"picked": [2, 0]}`), remoteValue(`[0, 2]`)},
+			`"arguments":[{"value":"label"},{"value":["Red","Blue"]}]`, ""},
+		// no choice deselects every option of a multi-select
+		{"no choices", OptionByValue, nil, []string{remoteValue(`{"picked": []}`), remoteValue(`[]`)},
+			`"arguments":[{"value":"value"},{"value":[]}]`, ""},
+		{"refused", OptionByIndex, []string{"7"}, []string{remoteValue(`{"error": "no option with index \"7\""}`)},
+			`"arguments":[{"value":"index"},{"value":["7"]}]`, `could not select [7] by index: no option with index "7"`},
+		{"changed by the page", OptionByValue, []string{"b"}, []string{remoteValue(`{"picked": [1]}`), remoteValue(`[0]`)},
+			`"arguments":[{"value":"value"},{"value":["b"]}]`, "the options [0] are selected instead of [1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := newFakeTab()
+			ft.answer("Runtime.callFunctionOn", tt.answers...)
+
+			err := selectOptions(ft.context(), &runtime.RemoteObject{ObjectID: "1"}, tt.by, tt.choices)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("selectOptions() error = %v, want %q", err, tt.wantErr)
+			}
+
+			calls := ft.sent("Runtime.callFunctionOn")
+			if len(calls) != len(tt.answers) || !strings.Contains(calls[0], tt.wantArgs) {
+				t.Errorf("called %q, want %d calls with %s", calls, len(tt.answers), tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSetChecked(t *testing.T) {
+	tests := []struct {
+		name    string
+		checked bool
+		answers []string
+		wantErr string
+	}{
+		{"checked", true, []string{remoteValue(`""`), remoteValue(`true`)}, ""},
+		{"unchecked", false, []string{remoteValue(`""`), remoteValue(`false`)}, ""},
+		{"disabled", true, []string{remoteValue(`"the checkbox is disabled"`)}, "could not set the checkbox: the checkbox is disabled"},
+		// a click handler of the page can keep the state
+		{"reverted", true, []string{remoteValue(`""`), remoteValue(`false`)}, "the checkbox is unchecked after the click"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := newFakeTab()
+			ft.answer("Runtime.callFunctionOn", tt.answers...)
+
+			err := setChecked(ft.context(), &runtime.RemoteObject{ObjectID: "1"}, "checkbox", tt.checked)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("setChecked() error = %v, want %q", err, tt.wantErr)
+			}
+			if calls := ft.sent("Runtime.callFunctionOn"); len(calls) != len(tt.answers) {
+				t.Errorf("%d calls, want %d", len(calls), len(tt.answers))
+			}
+		})
+	}
+}
+
+func TestOptionByString(t *testing.T) {
+	for by, want := range map[OptionBy]string{OptionByValue: "value", OptionByLabel: "label", OptionByIndex: "index"} {
+		if by.String() != want {
+			t.Errorf("OptionBy(%d).String() = %q, want %q", by, by.String(), want)
+		}
+	}
+}
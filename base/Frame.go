@@ -0,0 +1,575 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// errOutOfProcessFrame is returned by the actions on a frame running in another process than its page, because of site isolation
+var errOutOfProcessFrame = errors.New("the frame runs in another process, start the browser with --disable-features=site-per-process")
+
+type frameBy int
+
+const (
+	frameByElement frameBy = iota
+	frameByName
+	frameByURL
+)
+
+// FrameSelector chooses an iframe in the document of the parent frame
+type FrameSelector struct {
+	by    frameBy
+	value string
+	re    *regexp.Regexp
+}
+
+// FrameByElement chooses the iframe element matching the xpath selector in the parent document, like Iframe(1).String()
+func FrameByElement(selector string) FrameSelector {
+	return FrameSelector{by: frameByElement, value: selector}
+}
+
+// FrameByName chooses the first frame by its name attribute, in any depth under the parent frame
+func FrameByName(name string) FrameSelector {
+	return FrameSelector{by: frameByName, value: name}
+}
+
+// FrameByURL chooses the first frame by its url, a glob pattern like the url of InterceptRule, in any depth under the parent frame
+func FrameByURL(pattern string) FrameSelector {
+	return FrameSelector{by: frameByURL, value: pattern, re: regexp.MustCompile(globToRegexp(pattern))}
+}
+
+func (fs FrameSelector) String() string {
+	switch fs.by {
+	case frameByName:
+		return fmt.Sprintf("frame[name=%q]", fs.value)
+	case frameByURL:
+		return fmt.Sprintf("frame[url=%q]", fs.value)
+	}
+
+	return fs.value
+}
+
+func (fs FrameSelector) matches(frame *cdp.Frame) bool {
+	if fs.by == frameByName {
+		return frame.Name == fs.value
+	}
+
+	return fs.re.MatchString(frame.URL)
+}
+
+// FrameScope runs the actions in an iframe of the page. The frame is looked up again by every action, so it can be reloaded between them.
+// The elements are selected by xpath in the document of the frame, and the frame must run in the process of the page:
+// chrome started by New has the site isolation disabled, the actions on an out-of-process frame of a remote one fail with errOutOfProcessFrame.
+type FrameScope struct {
+	sm        *SiteManager
+	selectors []FrameSelector
+	// worlds are the isolated worlds created in the frames, until a script fails in them
+	worlds map[cdp.FrameID]runtime.ExecutionContextID
+}
+
+// InFrame returns the iframe chosen by the selectors, each selector looks for a frame in the frame chosen by the previous one
+func (sm *SiteManager) InFrame(selectors ...FrameSelector) *FrameScope {
+	return &FrameScope{sm: sm, selectors: selectors}
+}
+
+// InFrame returns an iframe nested in the frame
+func (f *FrameScope) InFrame(selectors ...FrameSelector) *FrameScope {
+	return &FrameScope{sm: f.sm, selectors: append(append([]FrameSelector(nil), f.selectors...), selectors...)}
+}
+
+func (f *FrameScope) String() string {
+	var parts []string
+	for _, fs := range f.selectors {
+		parts = append(parts, fs.String())
+	}
+
+	return strings.Join(parts, " > ")
+}
+
+func (f *FrameScope) WaitVisible(selector string, timeoutSec int64, handleError bool) error {
+	return f.do("WaitVisible", []interface{}{selector}, f.waitAction(selector, visibleCheck), timeoutSec, handleError)
+}
+
+func (f *FrameScope) WaitNotVisible(selector string, timeoutSec int64, handleError bool) error {
+	return f.do("WaitNotVisible", []interface{}{selector}, f.waitAction(selector, "!("+visibleCheck+")"), timeoutSec, handleError)
+}
+
+func (f *FrameScope) WaitReady(selector string, timeoutSec int64, handleError bool) error {
+	return f.do("WaitReady", []interface{}{selector}, f.waitAction(selector, "el !== null"), timeoutSec, handleError)
+}
+
+func (f *FrameScope) WaitNotPresent(selector string, timeoutSec int64, handleError bool) error {
+	return f.do("WaitNotPresent", []interface{}{selector}, f.waitAction(selector, "el === null"), timeoutSec, handleError)
+}
+
+func (f *FrameScope) WaitEnabled(selector string, timeoutSec int64, handleError bool) error {
+	return f.do("WaitEnabled", []interface{}{selector}, f.waitAction(selector, "el !== null && !el.disabled"), timeoutSec, handleError)
+}
+
+// ClickElement clicks the middle of the element by the mouse, like the ClickElement of the SiteManager
+func (f *FrameScope) ClickElement(selector string, timeoutSec int64, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return clickObject(ctx, el, 1)
+	})
+
+	return f.do("ClickElement", []interface{}{selector}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) DoubleClickElement(selector string, timeoutSec int64, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return clickObject(ctx, el, 2)
+	})
+
+	return f.do("DoubleClickElement", []interface{}{selector}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) FocusElement(selector string, timeoutSec int64, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return callOn(ctx, el, `function() { this.focus(); }`, nil)
+	})
+
+	return f.do("FocusElement", []interface{}{selector}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) ClearElement(selector string, timeoutSec int64, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return callOn(ctx, el, `function() {
+			this.value = '';
+			this.dispatchEvent(new Event('input', {bubbles: true}));
+			this.dispatchEvent(new Event('change', {bubbles: true}));
+		}`, nil)
+	})
+
+	return f.do("ClearElement", []interface{}{selector}, action, timeoutSec, handleError)
+}
+
+// FillField focuses the element and types the value into it by key events
+func (f *FrameScope) FillField(selector string, value string, timeoutSec int64, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		if err := callOn(ctx, el, `function() { this.focus(); }`, nil); err != nil {
+			return err
+		}
+
+		return chromedp.KeyEvent(value).Do(ctx)
+	})
+
+	return f.do("FillField", []interface{}{selector, value}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) ScrollTo(selector string, timeoutSec int64, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return callOn(ctx, el, scrollIntoViewJS, nil)
+	})
+
+	return f.do("ScrollTo", []interface{}{selector}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) TextInto(selector string, timeoutSec int64, text *string, handleError bool) error {
+	return f.do("TextInto", []interface{}{selector}, f.textAction(selector, text), timeoutSec, handleError)
+}
+
+func (f *FrameScope) InnerHTMLInto(selector string, timeoutSec int64, html *string, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return callOn(ctx, el, `function() { return this.innerHTML; }`, html)
+	})
+
+	return f.do("InnerHTMLInto", []interface{}{selector}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) GetElementAttributeValue(selector string, attribute string, into *string, ok *bool, timeoutSec int64, handleError bool) error {
+	return f.do("GetElementAttributeValue", []interface{}{selector, attribute}, f.attributeAction(selector, attribute, into, ok), timeoutSec, handleError)
+}
+
+func (f *FrameScope) AssertText(selector string, matcher Matcher, expected string, timeoutSec int64, handleError bool) error {
+	var actual string
+	action := f.sm.assertAction("AssertText", f.subject(selector), matcher, expected, &actual, f.textAction(selector, &actual))
+
+	return f.do("AssertText", []interface{}{selector, matcher, expected}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) AssertAttribute(selector string, attribute string, matcher Matcher, expected string, timeoutSec int64, handleError bool) error {
+	var actual string
+	var ok bool
+	fetch := chromedp.Tasks{
+		f.attributeAction(selector, attribute, &actual, &ok),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if !ok {
+				actual = "<missing attribute>"
+			}
+			return nil
+		}),
+	}
+	action := f.sm.assertAction("AssertAttribute", fmt.Sprintf("%s@%s", f.subject(selector), attribute), matcher, expected, &actual, fetch)
+
+	return f.do("AssertAttribute", []interface{}{selector, attribute, matcher, expected}, action, timeoutSec, handleError)
+}
+
+// Evaluate runs the javascript expression in the frame and sets its json result into the pointer.
+// It runs in an isolated world: it sees the document of the frame, but not the variables of its scripts.
+func (f *FrameScope) Evaluate(expression string, into interface{}, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		contextID, err := f.executionContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		res, err := evaluateIn(ctx, contextID, expression, true)
+		if err != nil {
+			f.worlds = nil
+			return err
+		}
+
+		return decodeRemoteValue(res, into)
+	})
+
+	return f.do("Evaluate", []interface{}{expression}, action, timeoutSec, handleError)
+}
+
+func (f *FrameScope) do(method string, args []interface{}, action chromedp.Action, timeoutSec int64, handleError bool) error {
+	if f.sm.activeGroup != "" {
+		f.sm.addGroupAction("Frame."+method, append([]interface{}{f.String()}, args...), action)
+		return nil
+	}
+
+	err := f.sm.DoTimeoutContext(timeoutSec, false, action)
+	f.sm.Error(err, handleError)
+
+	return err
+}
+
+func (f *FrameScope) subject(selector string) string {
+	return fmt.Sprintf("%s > %s", f, selector)
+}
+
+func (f *FrameScope) textAction(selector string, text *string) chromedp.Action {
+	return f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return callOn(ctx, el, `function() { return this.innerText; }`, text)
+	})
+}
+
+func (f *FrameScope) attributeAction(selector string, attribute string, into *string, ok *bool) chromedp.Action {
+	return f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		var value *string
+		err := callOn(ctx, el, `function(name) { return this.hasAttribute(name) ? this.getAttribute(name) : null; }`, &value, attribute)
+		if err != nil {
+			return err
+		}
+
+		*ok = value != nil
+		*into = ""
+		if value != nil {
+			*into = *value
+		}
+
+		return nil
+	})
+}
+
+const visibleCheck = `el !== null && !!(el.offsetWidth || el.offsetHeight || el.getClientRects().length)`
+
+const scrollIntoViewJS = `function() { this.scrollIntoView({block: 'center', inline: 'center'}); }`
+
+// findElementJS returns the first element of the xpath in the document of the frame, or null
+const findElementJS = `document.evaluate(%s, document, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue`
+
+// waitAction polls until the check is true for the first element of the selector, el is null in the check if there is no such element
+func (f *FrameScope) waitAction(selector string, check string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		_, err := f.waitElement(ctx, selector, check, false)
+		return err
+	})
+}
+
+// elementAction waits until the element of the selector is visible in the frame, then calls the function with it
+func (f *FrameScope) elementAction(selector string, fn func(ctx context.Context, el *runtime.RemoteObject) error) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		el, err := f.waitElement(ctx, selector, visibleCheck, true)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, el)
+	})
+}
+
+func (f *FrameScope) waitElement(ctx context.Context, selector string, check string, element bool) (*runtime.RemoteObject, error) {
+	xpath, err := json.Marshal(selector)
+	if err != nil {
+		return nil, err
+	}
+	find := fmt.Sprintf(findElementJS, xpath)
+	expression := fmt.Sprintf(`(function(el) { return %s; })(%s)`, check, find)
+
+	var lastErr error
+	for {
+		el, err := f.tryElement(ctx, expression, find, element)
+		if err == nil && (el != nil || !element) {
+			return el, nil
+		}
+		if errors.Is(err, errOutOfProcessFrame) {
+			return nil, err
+		}
+		if err != nil {
+			// the frame can be missing or loading yet, it is tried again until the timeout
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("%v: %v", ctx.Err(), lastErr)
+			}
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// tryElement checks the element once, it returns nil without error if the check is false
+func (f *FrameScope) tryElement(ctx context.Context, expression string, find string, element bool) (*runtime.RemoteObject, error) {
+	contextID, err := f.executionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	res, err := evaluateIn(ctx, contextID, expression, true)
+	if err != nil {
+		// the world is gone if the frame navigated, it is created again
+		f.worlds = nil
+		return nil, err
+	}
+	if err := decodeRemoteValue(res, &ok); err != nil || !ok {
+		return nil, err
+	}
+
+	if !element {
+		return nil, nil
+	}
+
+	el, err := evaluateIn(ctx, contextID, find, false)
+	if err != nil || el.ObjectID == "" {
+		return nil, err
+	}
+
+	return el, nil
+}
+
+// executionContext returns an isolated world of the frame, to run the scripts of the actions in it
+func (f *FrameScope) executionContext(ctx context.Context) (runtime.ExecutionContextID, error) {
+	tree, err := page.GetFrameTree().Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	current := tree
+	for _, fs := range f.selectors {
+		var next *page.FrameTree
+		if fs.by == frameByElement {
+			frameID, err := f.frameOfElement(ctx, current.Frame.ID, fs.value)
+			if err != nil {
+				return 0, err
+			}
+			next = findFrame(current, func(frame *cdp.Frame) bool {
+				return frame.ID == frameID
+			})
+		} else {
+			next = findFrame(current, fs.matches)
+		}
+
+		if next == nil {
+			return 0, fmt.Errorf("no frame %s", fs)
+		}
+		current = next
+	}
+
+	return f.isolatedWorld(ctx, current.Frame.ID)
+}
+
+// frameOfElement returns the frame loaded into the iframe element of the xpath
+func (f *FrameScope) frameOfElement(ctx context.Context, parent cdp.FrameID, selector string) (cdp.FrameID, error) {
+	contextID, err := f.isolatedWorld(ctx, parent)
+	if err != nil {
+		return "", err
+	}
+
+	xpath, err := json.Marshal(selector)
+	if err != nil {
+		return "", err
+	}
+
+	el, err := evaluateIn(ctx, contextID, fmt.Sprintf(findElementJS, xpath), false)
+	if err != nil {
+		f.worlds = nil
+		return "", err
+	}
+	if el.ObjectID == "" {
+		return "", fmt.Errorf("no iframe element %s", selector)
+	}
+
+	node, err := dom.DescribeNode().WithObjectID(el.ObjectID).Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	if node.FrameID == "" {
+		return "", fmt.Errorf("element %s is not an iframe", selector)
+	}
+
+	return node.FrameID, nil
+}
+
+// findFrame returns the first frame under the tree matching the function, the closer ones first
+func findFrame(tree *page.FrameTree, match func(frame *cdp.Frame) bool) *page.FrameTree {
+	queue := append([]*page.FrameTree(nil), tree.ChildFrames...)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if match(current.Frame) {
+			return current
+		}
+		queue = append(queue, current.ChildFrames...)
+	}
+
+	return nil
+}
+
+func (f *FrameScope) isolatedWorld(ctx context.Context, frameID cdp.FrameID) (runtime.ExecutionContextID, error) {
+	if contextID, ok := f.worlds[frameID]; ok {
+		return contextID, nil
+	}
+
+	oopif, err := outOfProcess(ctx, frameID)
+	if err != nil {
+		return 0, err
+	}
+	if oopif {
+		return 0, fmt.Errorf("frame %s: %w", frameID, errOutOfProcessFrame)
+	}
+
+	contextID, err := page.CreateIsolatedWorld(frameID).WithWorldName("watat").Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if f.worlds == nil {
+		f.worlds = make(map[cdp.FrameID]runtime.ExecutionContextID)
+	}
+	f.worlds[frameID] = contextID
+
+	return contextID, nil
+}
+
+// outOfProcess tells if the frame runs in its own process, the browser has a target of the id of such a frame
+func outOfProcess(ctx context.Context, frameID cdp.FrameID) (bool, error) {
+	infos, err := target.GetTargets().Do(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, info := range infos {
+		if info.Type == "iframe" && string(info.TargetID) == string(frameID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func evaluateIn(ctx context.Context, contextID runtime.ExecutionContextID, expression string, byValue bool) (*runtime.RemoteObject, error) {
+	res, exp, err := runtime.Evaluate(expression).WithContextID(contextID).WithReturnByValue(byValue).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exp != nil {
+		return nil, exp
+	}
+
+	return res, nil
+}
+
+// callOn calls the javascript function on the element with the arguments, and sets its json result into the pointer if it is not nil
+func callOn(ctx context.Context, el *runtime.RemoteObject, function string, into interface{}, args ...interface{}) error {
+	var arguments []*runtime.CallArgument
+	for _, arg := range args {
+		value, err := json.Marshal(arg)
+		if err != nil {
+			return err
+		}
+		arguments = append(arguments, &runtime.CallArgument{Value: value})
+	}
+
+	res, exp, err := runtime.CallFunctionOn(function).
+		WithObjectID(el.ObjectID).
+		WithArguments(arguments).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exp != nil {
+		return exp
+	}
+
+	if into == nil {
+		return nil
+	}
+
+	return decodeRemoteValue(res, into)
+}
+
+func decodeRemoteValue(res *runtime.RemoteObject, into interface{}) error {
+	if res.Type == runtime.TypeUndefined {
+		return errors.New("the script returned undefined")
+	}
+	if len(res.Value) == 0 {
+		// null
+		return json.Unmarshal([]byte("null"), into)
+	}
+
+	return json.Unmarshal(res.Value, into)
+}
+
+// clickObject scrolls the element into view and clicks its middle clickCount times
+func clickObject(ctx context.Context, el *runtime.RemoteObject, clickCount int) error {
+	x, y, err := elementCenter(ctx, el)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= clickCount; i++ {
+		if err := chromedp.MouseClickXY(x, y, chromedp.ClickCount(i)).Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quadsCenter returns the middle of the first quad of the element, in the coordinates of the viewport
+func quadsCenter(quads []dom.Quad) (float64, float64, error) {
+	if len(quads) == 0 || len(quads[0]) < 2 || len(quads[0])%2 != 0 {
+		return 0, 0, chromedp.ErrInvalidDimensions
+	}
+
+	var x, y float64
+	quad := quads[0]
+	for i := 0; i < len(quad); i += 2 {
+		x += quad[i]
+		y += quad[i+1]
+	}
+	points := float64(len(quad) / 2)
+
+	return x / points, y / points, nil
+}
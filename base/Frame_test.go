@@ -0,0 +1,154 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"testing"
+	"time"
+)
+
+func TestFrameSelectorMatches(t *testing.T) {
+	frame := &cdp.Frame{ID: "pay", Name: "payment", URL: "https://pay.example/checkout?id=1"}
+
+	tests := []struct {
+		selector FrameSelector
+		want     bool
+	}{
+		{FrameByName("payment"), true},
+		{FrameByName("pay"), false},
+		{FrameByURL("https://pay.example/*"), true},
+		{FrameByURL("*/checkout?id=?"), true},
+		{FrameByURL("https://pay.example/"), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.selector.matches(frame); got != tt.want {
+			t.Errorf("%s.matches(%s) = %v, want %v", tt.selector, frame.URL, got, tt.want)
+		}
+	}
+}
+
+// frameTree is the page of shop.example with a banner frame, and a payment frame holding a card frame
+func frameTree() *page.FrameTree {
+	return &page.FrameTree{
+		Frame: &cdp.Frame{ID: "main", URL: "https://shop.example/"},
+		ChildFrames: []*page.FrameTree{
+			{
+				Frame: &cdp.Frame{ID: "ads", Name: "banner", URL: "https://ads.example/"},
+				ChildFrames: []*page.FrameTree{
+					{Frame: &cdp.Frame{ID: "ads-card", Name: "card", URL: "https://ads.example/card"}},
+				},
+			},
+			{
+				Frame: &cdp.Frame{ID: "pay", Name: "payment", URL: "https://pay.example/"},
+				ChildFrames: []*page.FrameTree{
+					{Frame: &cdp.Frame{ID: "card", Name: "card", URL: "https://pay.example/card"}},
+				},
+			},
+			{Frame: &cdp.Frame{ID: "card-top", Name: "top", URL: "https://pay.example/card"}},
+		},
+	}
+}
+
+func TestFindFrame(t *testing.T) {
+	tree := frameTree()
+
+	tests := []struct {
+		name     string
+		tree     *page.FrameTree
+		selector FrameSelector
+		want     cdp.FrameID
+	}{
+		{"child", tree, FrameByName("payment"), "pay"},
+		{"grandchild", tree, FrameByName("card"), "ads-card"},
+		// a child frame is found before a deeper one of the same url
+		{"closer first", tree, FrameByURL("*/card"), "card-top"},
+		{"under the parent", tree.ChildFrames[1], FrameByName("card"), "card"},
+		// the parent frame itself is not a match
+		{"not the parent", tree.ChildFrames[1], FrameByName("payment"), ""},
+		{"missing", tree, FrameByName("chat"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got cdp.FrameID
+			if found := findFrame(tt.tree, tt.selector.matches); found != nil {
+				got = found.Frame.ID
+			}
+			if got != tt.want {
+				t.Errorf("findFrame(%s) = %q, want %q", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+const frameTreeJSON = `{"frameTree": {"frame": {"id": "main", "url": "https://shop.example/"}, "childFrames": [
+	{"frame": {"id": "pay", "name": "payment", "url": "https://pay.example/"}, "childFrames": [
+		{"frame": {"id": "card", "name": "card", "url": "https://pay.example/card"}}
+	]}
+]}}`
+
+func TestFrameExecutionContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets string
+		wantErr error
+	}{
+		{"in process", `{"targetInfos": [{"targetId": "main", "type": "page"}]}`, nil},
+		// with site isolation the frame is a target of its own
+		{"out of process", `{"targetInfos": [{"targetId": "main", "type": "page"}, {"targetId": "card", "type": "iframe"}]}`, errOutOfProcessFrame},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := newFakeTab()
+			ft.answer("Page.getFrameTree", frameTreeJSON)
+			ft.answer("Target.getTargets", tt.targets)
+			ft.answer("Page.createIsolatedWorld", `{"executionContextId": 7}`)
+
+			f := newTestManager().InFrame(FrameByName("payment")).InFrame(FrameByURL("*/card"))
+			contextID, err := f.executionContext(ft.context())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("executionContext() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(ft.sent("Page.createIsolatedWorld")) != 0 {
+					t.Error("isolated world created in an out-of-process frame")
+				}
+				return
+			}
+
+			if contextID != 7 {
+				t.Errorf("executionContext() = %d, want 7", contextID)
+			}
+			if worlds := ft.sent("Page.createIsolatedWorld"); len(worlds) != 1 || worlds[0] != `{"frameId":"card","worldName":"watat"}` {
+				t.Errorf("isolated worlds created by %q", worlds)
+			}
+
+			// the world is reused by the next action
+			if _, err := f.executionContext(ft.context()); err != nil || len(ft.sent("Page.createIsolatedWorld")) != 1 {
+				t.Errorf("executionContext() again error = %v, %d worlds created", err, len(ft.sent("Page.createIsolatedWorld")))
+			}
+		})
+	}
+}
+
+func TestFrameOutOfProcessFailsAtOnce(t *testing.T) {
+	ft := newFakeTab()
+	ft.answer("Page.getFrameTree", frameTreeJSON)
+	ft.answer("Target.getTargets", `{"targetInfos": [{"targetId": "pay", "type": "iframe"}]}`)
+
+	sm := newTestManager()
+	sm.Group("frame")
+	sm.InFrame(FrameByName("payment")).WaitReady("//button", 0, false)
+
+	ctx, cancel := context.WithTimeout(ft.context(), 5*time.Second)
+	defer cancel()
+
+	// the frame does not come into the process of the page by waiting
+	if err := sm.groupActions["frame"][0].action.Do(ctx); !errors.Is(err, errOutOfProcessFrame) || ctx.Err() != nil {
+		t.Errorf("WaitReady() error = %v, want %v before the timeout", err, errOutOfProcessFrame)
+	}
+}
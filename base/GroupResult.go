@@ -0,0 +1,240 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	"strings"
+	"time"
+)
+
+type StepStatus string
+
+const (
+	StepPassed  StepStatus = "passed"
+	StepFailed  StepStatus = "failed"
+	StepSkipped StepStatus = "skipped"
+)
+
+// groupAction is one recorded action of a group, with the SiteManager method and arguments it was recorded by
+type groupAction struct {
+	name   string
+	method string
+	args   []interface{}
+	action chromedp.Action
+}
+
+type StepResult struct {
+	Index      int
+	Name       string
+	Method     string
+	Args       []interface{}
+	Status     StepStatus
+	Start      time.Time
+	Duration   time.Duration
+	Err        error
+	Screenshot []byte
+
+	ConsoleMessages []ConsoleMessage
+	PageErrors      []PageError
+}
+
+func (sr StepResult) String() string {
+	var args []string
+	for _, arg := range sr.Args {
+		args = append(args, fmt.Sprintf("%v", arg))
+	}
+
+	line := fmt.Sprintf("#%d %s %s(%s) %s %v", sr.Index, sr.Name, sr.Method, strings.Join(args, ", "), sr.Status, sr.Duration)
+	if sr.Err != nil {
+		line += ": " + sr.Err.Error()
+	}
+
+	return line
+}
+
+type GroupResult struct {
+	Group    string
+	Start    time.Time
+	Duration time.Duration
+	Steps    []StepResult
+	Err      error
+}
+
+// GroupError is the error of a group failed by a step after soft assertions of the earlier steps failed, it tells both
+type GroupError struct {
+	Err          error
+	SoftFailures AssertionErrors
+}
+
+func (ge GroupError) Error() string {
+	return fmt.Sprintf("%v\nafter %v", ge.Err, ge.SoftFailures)
+}
+
+func (ge GroupError) Unwrap() error {
+	return ge.Err
+}
+
+// groupError returns the error of the failing step, the soft assertion failures, or both
+func groupError(err error, softFailures AssertionErrors) error {
+	if len(softFailures) == 0 {
+		return err
+	}
+	if err == nil {
+		return softFailures
+	}
+
+	return GroupError{Err: err, SoftFailures: softFailures}
+}
+
+func (gr GroupResult) Failed() bool {
+	return gr.Err != nil
+}
+
+func (gr GroupResult) FailedSteps() []StepResult {
+	var failed []StepResult
+	for _, step := range gr.Steps {
+		if step.Status == StepFailed {
+			failed = append(failed, step)
+		}
+	}
+
+	return failed
+}
+
+func (gr GroupResult) String() string {
+	lines := []string{fmt.Sprintf("group %s finished in %v", gr.Group, gr.Duration)}
+	for _, step := range gr.Steps {
+		lines = append(lines, step.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// runGroup executes the actions one by one, after the first failing step the rest are skipped
+func (sm *SiteManager) runGroup(group string, actions []groupAction, timeoutSec int64) *GroupResult {
+	if timeoutSec == 0 && sm.timeoutSec > 0 {
+		timeoutSec = sm.timeoutSec
+	}
+
+	var deadline time.Time
+	if timeoutSec > 0 {
+		deadline = time.Now().Add(sm.GetTimeoutDurationSecs(timeoutSec))
+	}
+
+	// actions calling SiteManager methods must run now instead of being recorded again
+	activeGroup := sm.activeGroup
+	sm.activeGroup = ""
+	defer func() {
+		sm.activeGroup = activeGroup
+	}()
+
+	result := &GroupResult{Group: group, Start: time.Now()}
+	softFailures := len(sm.assertFailures)
+
+	err := sm.runStep(deadline, sm.fixActions...)
+
+	for i, ga := range actions {
+		step := StepResult{
+			Index:  i,
+			Name:   ga.name,
+			Method: ga.method,
+			Args:   ga.args,
+			Status: StepSkipped,
+		}
+
+		if err != nil {
+			result.Steps = append(result.Steps, step)
+			continue
+		}
+
+		stepFailures := len(sm.assertFailures)
+		sm.console.setStep(group, i)
+		consoleMark := sm.console.mark()
+		step.Start = time.Now()
+		sm.runningStep = &step
+		err = sm.runStep(deadline, ga.action)
+		sm.runningStep = nil
+		step.Duration = time.Since(step.Start)
+		step.Status = StepPassed
+
+		step.ConsoleMessages, step.PageErrors = sm.console.since(consoleMark)
+		if err == nil && sm.failOnConsoleError {
+			err = consoleFailure(step.ConsoleMessages, step.PageErrors)
+		}
+
+		if err != nil {
+			step.Status = StepFailed
+			step.Err = err
+			if sm.failureScreenshot {
+				step.Screenshot = sm.captureFailure()
+			}
+		} else if len(sm.assertFailures) > stepFailures {
+			// soft assertion failed, the following steps still run
+			step.Status = StepFailed
+			step.Err = sm.assertFailures[stepFailures:]
+		}
+
+		result.Steps = append(result.Steps, step)
+	}
+
+	sm.console.setStep("", -1)
+
+	result.Duration = time.Since(result.Start)
+	// soft assertion failures are reported only after every action of the group ran
+	result.Err = groupError(err, sm.assertFailures[softFailures:])
+
+	return result
+}
+
+// runStep runs the actions until the deadline of the group, on the tab active right now since a step can switch tabs
+func (sm *SiteManager) runStep(deadline time.Time, actions ...chromedp.Action) error {
+	if sm.ctx == nil {
+		return errNotStarted
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if deadline.IsZero() {
+		ctx, cancel = context.WithCancel(sm.ctx)
+	} else {
+		ctx, cancel = context.WithDeadline(sm.ctx, deadline)
+	}
+	defer cancel()
+
+	return sm.run(ctx, actions...)
+}
+
+// consoleFailure returns the error level messages and the exceptions as error, or nil if there is none
+func consoleFailure(messages []ConsoleMessage, errors []PageError) error {
+	ce := ConsoleErrors{Errors: errors}
+	for _, cm := range messages {
+		if cm.IsError() {
+			ce.Messages = append(ce.Messages, cm)
+		}
+	}
+
+	if len(ce.Messages) == 0 && len(ce.Errors) == 0 {
+		return nil
+	}
+
+	return ce
+}
+
+// captureFailure takes the screenshot of a failed step, it can not fail the step itself.
+// The context of the session may be over when a step timed out, so the screenshot is taken on the context of the tab.
+func (sm *SiteManager) captureFailure() []byte {
+	if sm.tabCtx == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(sm.tabCtx, 5*time.Second)
+	defer cancel()
+
+	var shot []byte
+	if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&shot)); err != nil {
+		return nil
+	}
+
+	return shot
+}
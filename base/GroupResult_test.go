@@ -0,0 +1,56 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGroupError(t *testing.T) {
+	soft := AssertionErrors{{Assertion: "AssertText", Subject: "h1", Matcher: MatchEqual, Expected: "Welcome", Actual: "Hello"}}
+
+	tests := []struct {
+		name      string
+		err       error
+		soft      AssertionErrors
+		wantNil   bool
+		wantParts []string
+	}{
+		{"passed", nil, nil, true, nil},
+		{"passed with empty failures", nil, AssertionErrors{}, true, nil},
+		{"hard only", context.DeadlineExceeded, nil, false, []string{"context deadline exceeded"}},
+		{"soft only", nil, soft, false, []string{"1 assertion(s) failed", "Welcome"}},
+		// the soft failures of the earlier steps are kept when a later step fails
+		{"both", context.DeadlineExceeded, soft, false, []string{"context deadline exceeded", "1 assertion(s) failed", "Welcome"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := groupError(tt.err, tt.soft)
+			if (err == nil) != tt.wantNil {
+				t.Fatalf("groupError() = %v, want nil %v", err, tt.wantNil)
+			}
+			for _, part := range tt.wantParts {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("groupError() = %q, want it to contain %q", err, part)
+				}
+			}
+		})
+	}
+
+	err := groupError(context.DeadlineExceeded, soft)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("groupError() = %v does not wrap the error of the step", err)
+	}
+	if ge, ok := err.(GroupError); !ok || len(ge.SoftFailures) != 1 {
+		t.Errorf("groupError() = %#v, want a GroupError with the soft failure", err)
+	}
+}
+
+func TestCaptureFailureWithoutBrowser(t *testing.T) {
+	sm := newTestManager()
+	if shot := sm.captureFailure(); shot != nil {
+		t.Errorf("captureFailure() = %d bytes without a browser", len(shot))
+	}
+}
@@ -0,0 +1,468 @@
+package base
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type harEntry struct {
+	entry    *har.Entry
+	sent     time.Time
+	received time.Time
+	finished bool
+}
+
+// harRecorder builds the har entries from the events of the Network domain
+type harRecorder struct {
+	mu      sync.Mutex
+	entries []*harEntry
+	pending map[network.RequestID]*harEntry
+}
+
+// harRecording keeps the recorder between StartHAR and StopHAR, the network events read it on other goroutines
+type harRecording struct {
+	mu       sync.Mutex
+	recorder *harRecorder
+}
+
+func (r *harRecording) current() *harRecorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.recorder
+}
+
+func (r *harRecording) set(recorder *harRecorder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recorder = recorder
+}
+
+// StartHAR starts recording every request and response of the tab
+func (sm *SiteManager) StartHAR(timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		sm.har.set(&harRecorder{pending: make(map[network.RequestID]*harEntry)})
+		sm.listen("har", sm.onNetworkEvent)
+		return network.Enable().Do(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("StartHAR", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// SaveHAR writes the finished requests recorded since StartHAR into a HAR 1.2 file, the recording goes on
+func (sm *SiteManager) SaveHAR(path string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		recorder := sm.har.current()
+		if recorder == nil {
+			return errors.New("har recording has not been started")
+		}
+
+		content, err := json.MarshalIndent(recorder.log(), "", "  ")
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(path, content, 0644)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SaveHAR", []interface{}{path}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) StopHAR(timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		sm.har.set(nil)
+		return nil
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("StopHAR", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// ReplayHAR answers every request from the recorded har file, the requests not in the file fail as if the network was down.
+// The intercept rules are still checked before the har entries.
+func (sm *SiteManager) ReplayHAR(path string, timeoutSec int64, handleError bool) error {
+	replay, err := loadHAR(path)
+	if err != nil {
+		sm.Error(err, handleError)
+		return err
+	}
+
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		sm.interceptor.setReplay(replay)
+		return sm.enableFetch(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ReplayHAR", []interface{}{path}, action)
+		return nil
+	}
+
+	err = sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) onNetworkEvent(ctx context.Context, ev interface{}) {
+	recorder := sm.har.current()
+	if recorder == nil {
+		return
+	}
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		recorder.requestWillBeSent(e)
+	case *network.EventResponseReceived:
+		recorder.responseReceived(e)
+	case *network.EventLoadingFinished:
+		go func() {
+			// the body has to be requested before the tab forgets it
+			var body []byte
+			err := execute(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+				var err error
+				body, err = network.GetResponseBody(e.RequestID).Do(ctx)
+				return err
+			}))
+			if err != nil {
+				sm.logf("could not get the body of request %s: %v", e.RequestID, err)
+			}
+			recorder.loadingFinished(e, body)
+		}()
+	case *network.EventLoadingFailed:
+		recorder.loadingFailed(e)
+	}
+}
+
+func (hr *harRecorder) requestWillBeSent(e *network.EventRequestWillBeSent) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	if prev, ok := hr.pending[e.RequestID]; ok && e.RedirectResponse != nil {
+		// the same request id is used by the request after a redirect
+		prev.entry.Response = harResponse(e.RedirectResponse)
+		prev.received = e.Timestamp.Time()
+		prev.finish(e.Timestamp.Time())
+	}
+
+	he := &harEntry{
+		entry: &har.Entry{
+			StartedDateTime: e.WallTime.Time().Format(time.RFC3339Nano),
+			Request:         harRequest(e.Request),
+			Cache:           &har.Cache{},
+		},
+		sent: e.Timestamp.Time(),
+	}
+
+	hr.pending[e.RequestID] = he
+	hr.entries = append(hr.entries, he)
+}
+
+func (hr *harRecorder) responseReceived(e *network.EventResponseReceived) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	if he, ok := hr.pending[e.RequestID]; ok {
+		he.entry.Response = harResponse(e.Response)
+		he.entry.ServerIPAddress = e.Response.RemoteIPAddress
+		he.received = e.Timestamp.Time()
+	}
+}
+
+func (hr *harRecorder) loadingFinished(e *network.EventLoadingFinished, body []byte) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	he, ok := hr.pending[e.RequestID]
+	if !ok || he.entry.Response == nil {
+		return
+	}
+	delete(hr.pending, e.RequestID)
+
+	content := he.entry.Response.Content
+	content.Size = int64(len(body))
+	if isTextMimeType(content.MimeType) {
+		content.Text = string(body)
+	} else if len(body) > 0 {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	he.entry.Response.BodySize = int64(e.EncodedDataLength)
+
+	he.finish(e.Timestamp.Time())
+}
+
+func (hr *harRecorder) loadingFailed(e *network.EventLoadingFailed) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	he, ok := hr.pending[e.RequestID]
+	if !ok {
+		return
+	}
+	delete(hr.pending, e.RequestID)
+
+	if he.entry.Response == nil {
+		he.entry.Response = &har.Response{
+			Headers: []*har.NameValuePair{},
+			Cookies: []*har.Cookie{},
+			Content: &har.Content{},
+		}
+	}
+	he.entry.Response.Comment = e.ErrorText
+	he.finish(e.Timestamp.Time())
+}
+
+func (he *harEntry) finish(end time.Time) {
+	wait := he.received.Sub(he.sent)
+	receive := end.Sub(he.received)
+	if he.received.IsZero() {
+		wait, receive = end.Sub(he.sent), 0
+	}
+
+	he.entry.Timings = &har.Timings{
+		Send:    0,
+		Wait:    milliseconds(wait),
+		Receive: milliseconds(receive),
+	}
+	he.entry.Time = milliseconds(end.Sub(he.sent))
+	he.finished = true
+}
+
+func (hr *harRecorder) log() *har.HAR {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	l := &har.Log{
+		Version: "1.2",
+		Creator: &har.Creator{Name: "webdice-atat", Version: "1.0"},
+		Entries: []*har.Entry{},
+	}
+
+	for _, he := range hr.entries {
+		if he.finished {
+			l.Entries = append(l.Entries, he.entry)
+		}
+	}
+
+	return &har.HAR{Log: l}
+}
+
+func harRequest(r *network.Request) *har.Request {
+	hr := &har.Request{
+		Method:      r.Method,
+		URL:         r.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []*har.Cookie{},
+		Headers:     harHeaders(r.Headers),
+		QueryString: []*har.NameValuePair{},
+		HeadersSize: -1,
+		BodySize:    int64(len(r.PostData)),
+	}
+
+	if u, err := url.Parse(r.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				hr.QueryString = append(hr.QueryString, &har.NameValuePair{Name: name, Value: value})
+			}
+		}
+	}
+
+	if r.HasPostData {
+		hr.PostData = &har.PostData{
+			MimeType: headerValue(r.Headers, "Content-Type"),
+			Params:   []*har.Param{},
+			Text:     r.PostData,
+		}
+	}
+
+	return hr
+}
+
+func harResponse(r *network.Response) *har.Response {
+	protocol := strings.ToUpper(r.Protocol)
+	if protocol == "" {
+		protocol = "HTTP/1.1"
+	}
+
+	return &har.Response{
+		Status:      r.Status,
+		StatusText:  r.StatusText,
+		HTTPVersion: protocol,
+		Cookies:     []*har.Cookie{},
+		Headers:     harHeaders(r.Headers),
+		Content:     &har.Content{MimeType: r.MimeType},
+		RedirectURL: headerValue(r.Headers, "Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+func harHeaders(headers network.Headers) []*har.NameValuePair {
+	pairs := []*har.NameValuePair{}
+	for name, value := range decodeHeaders(headers) {
+		// multiple values of one header are joined by new lines
+		for _, v := range strings.Split(value, "\n") {
+			pairs = append(pairs, &har.NameValuePair{Name: name, Value: v})
+		}
+	}
+
+	return pairs
+}
+
+func headerValue(headers network.Headers, name string) string {
+	for key, value := range decodeHeaders(headers) {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// decodeHeaders reads the headers object sent by the browser as raw json
+func decodeHeaders(headers network.Headers) map[string]string {
+	decoded := make(map[string]string)
+	if len(headers) == 0 {
+		return decoded
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(headers, &values); err != nil {
+		return decoded
+	}
+
+	for name, value := range values {
+		decoded[name] = fmt.Sprintf("%v", value)
+	}
+
+	return decoded
+}
+
+func isTextMimeType(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	for _, text := range []string{"text/", "json", "javascript", "xml", "html", "css", "svg"} {
+		if strings.Contains(mimeType, text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harReplay serves the recorded responses by method and url, the same request gets the entries in recorded order
+type harReplay struct {
+	entries map[string][]*har.Entry
+	served  map[string]int
+}
+
+func loadHAR(path string) (*harReplay, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var h har.HAR
+	if err := json.Unmarshal(content, &h); err != nil {
+		return nil, fmt.Errorf("could not parse har file %s: %v", path, err)
+	}
+	if h.Log == nil {
+		return nil, fmt.Errorf("har file %s has no log", path)
+	}
+
+	replay := &harReplay{
+		entries: make(map[string][]*har.Entry),
+		served:  make(map[string]int),
+	}
+	for _, entry := range h.Log.Entries {
+		if entry.Request == nil || entry.Response == nil {
+			continue
+		}
+		key := replayKey(entry.Request.Method, entry.Request.URL)
+		replay.entries[key] = append(replay.entries[key], entry)
+	}
+
+	return replay, nil
+}
+
+func replayKey(method, url string) string {
+	return strings.ToUpper(method) + " " + url
+}
+
+// next returns the next recorded entry of the request, when all were served the last one is repeated
+func (hr *harReplay) next(request *network.Request) (*har.Entry, bool) {
+	key := replayKey(request.Method, request.URL)
+	entries := hr.entries[key]
+	if len(entries) == 0 {
+		return nil, false
+	}
+
+	i := hr.served[key]
+	if i >= len(entries) {
+		i = len(entries) - 1
+	}
+	hr.served[key] = i + 1
+
+	return entries[i], true
+}
+
+func replayResponse(id fetch.RequestID, entry *har.Entry) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		response := entry.Response
+
+		var headers []*fetch.HeaderEntry
+		for _, header := range response.Headers {
+			// the recorded body is already decoded
+			if strings.EqualFold(header.Name, "Content-Encoding") || strings.EqualFold(header.Name, "Content-Length") {
+				continue
+			}
+			headers = append(headers, &fetch.HeaderEntry{Name: header.Name, Value: header.Value})
+		}
+
+		body := ""
+		if response.Content != nil {
+			if response.Content.Encoding == "base64" {
+				body = response.Content.Text
+			} else {
+				body = base64.StdEncoding.EncodeToString([]byte(response.Content.Text))
+			}
+		}
+
+		return fetch.FulfillRequest(id, response.Status).WithResponseHeaders(headers).WithBody(body).Do(ctx)
+	})
+}
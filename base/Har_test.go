@@ -0,0 +1,201 @@
+package base
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/har"
+	"github.com/chromedp/cdproto/network"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var harStart = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func monotonic(d time.Duration) *cdp.MonotonicTime {
+	t := cdp.MonotonicTime(harStart.Add(d))
+	return &t
+}
+
+func wallTime(d time.Duration) *cdp.TimeSinceEpoch {
+	t := cdp.TimeSinceEpoch(harStart.Add(d))
+	return &t
+}
+
+func requestSent(id string, method string, url string, at time.Duration) *network.EventRequestWillBeSent {
+	return &network.EventRequestWillBeSent{
+		RequestID: network.RequestID(id),
+		Request:   &network.Request{Method: method, URL: url, Headers: network.Headers(`{"Accept": "*/*"}`)},
+		Timestamp: monotonic(at),
+		WallTime:  wallTime(at),
+	}
+}
+
+func responseReceived(id string, status int64, mimeType string, at time.Duration) *network.EventResponseReceived {
+	return &network.EventResponseReceived{
+		RequestID: network.RequestID(id),
+		Response:  &network.Response{Status: status, MimeType: mimeType, Headers: network.Headers(`{"Content-Type": "` + mimeType + `"}`)},
+		Timestamp: monotonic(at),
+	}
+}
+
+func TestHarRecorder(t *testing.T) {
+	hr := &harRecorder{pending: make(map[network.RequestID]*harEntry)}
+
+	hr.requestWillBeSent(requestSent("1", "GET", "https://example.com/api?q=a&q=b", 0))
+	hr.responseReceived(responseReceived("1", 200, "application/json", 100*time.Millisecond))
+	hr.loadingFinished(&network.EventLoadingFinished{RequestID: "1", Timestamp: monotonic(150 * time.Millisecond), EncodedDataLength: 11}, []byte(`{"ok":true}`))
+
+	post := requestSent("2", "POST", "https://example.com/login", 10*time.Millisecond)
+	post.Request.HasPostData = true
+	post.Request.PostData = "user=a"
+	hr.requestWillBeSent(post)
+	hr.loadingFailed(&network.EventLoadingFailed{RequestID: "2", Timestamp: monotonic(30 * time.Millisecond), ErrorText: "net::ERR_FAILED"})
+
+	hr.requestWillBeSent(requestSent("3", "GET", "https://example.com/old", 20*time.Millisecond))
+	redirected := requestSent("3", "GET", "https://example.com/new", 40*time.Millisecond)
+	redirected.RedirectResponse = &network.Response{Status: 302, Headers: network.Headers(`{"Location": "/new"}`)}
+	hr.requestWillBeSent(redirected)
+
+	hr.requestWillBeSent(requestSent("4", "GET", "https://example.com/logo.png", 50*time.Millisecond))
+	hr.responseReceived(responseReceived("4", 200, "image/png", 60*time.Millisecond))
+	hr.loadingFinished(&network.EventLoadingFinished{RequestID: "4", Timestamp: monotonic(70 * time.Millisecond)}, []byte{0x89, 'P', 'N', 'G'})
+
+	entries := hr.log().Log.Entries
+	// the request after the redirect has not finished
+	if len(entries) != 4 {
+		t.Fatalf("%d entries logged, want 4", len(entries))
+	}
+
+	api := entries[0]
+	if api.Response.Status != 200 || api.Response.Content.Text != `{"ok":true}` || api.Response.Content.Size != 11 || api.Response.BodySize != 11 {
+		t.Errorf("api response = %+v, content %+v", api.Response, api.Response.Content)
+	}
+	if api.Time != 150 || api.Timings.Wait != 100 || api.Timings.Receive != 50 {
+		t.Errorf("api timings = %v ms, %+v", api.Time, api.Timings)
+	}
+	if len(api.Request.QueryString) != 2 || len(api.Request.Headers) != 1 || api.StartedDateTime != "2020-01-02T03:04:05Z" {
+		t.Errorf("api request = %+v", api.Request)
+	}
+
+	login := entries[1]
+	if login.Request.PostData == nil || login.Request.PostData.Text != "user=a" || login.Response.Comment != "net::ERR_FAILED" || login.Timings.Wait != 20 {
+		t.Errorf("failed login = %+v, %+v", login.Request, login.Response)
+	}
+
+	old := entries[2]
+	if old.Request.URL != "https://example.com/old" || old.Response.Status != 302 || old.Response.RedirectURL != "/new" {
+		t.Errorf("redirect = %s %+v", old.Request.URL, old.Response)
+	}
+
+	logo := entries[3]
+	if logo.Response.Content.Encoding != "base64" || logo.Response.Content.Text != base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("binary content = %+v", logo.Response.Content)
+	}
+}
+
+func writeHAR(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "recorded.har")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestHarReplay(t *testing.T) {
+	h := har.HAR{Log: &har.Log{Entries: []*har.Entry{
+		{Request: &har.Request{Method: "GET", URL: "https://example.com/counter"}, Response: &har.Response{Status: 200, StatusText: "first"}},
+		{Request: &har.Request{Method: "POST", URL: "https://example.com/counter"}, Response: &har.Response{Status: 201}},
+		{Request: &har.Request{Method: "GET", URL: "https://example.com/counter"}, Response: &har.Response{Status: 200, StatusText: "second"}},
+		{Request: &har.Request{Method: "GET", URL: "https://example.com/failed"}},
+	}}}
+	content, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := loadHAR(writeHAR(t, string(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		url    string
+		want   string
+		ok     bool
+	}{
+		{"GET", "https://example.com/counter", "first", true},
+		{"get", "https://example.com/counter", "second", true},
+		// the last recorded response is repeated
+		{"GET", "https://example.com/counter", "second", true},
+		{"POST", "https://example.com/counter", "", true},
+		{"GET", "https://example.com/failed", "", false},
+		{"GET", "https://example.com/counter?page=2", "", false},
+	}
+
+	for _, tt := range tests {
+		entry, ok := replay.next(&network.Request{Method: tt.method, URL: tt.url})
+		if ok != tt.ok {
+			t.Errorf("next(%s %s) found %v, want %v", tt.method, tt.url, ok, tt.ok)
+			continue
+		}
+		if ok && entry.Response.StatusText != tt.want {
+			t.Errorf("next(%s %s) = %q, want %q", tt.method, tt.url, entry.Response.StatusText, tt.want)
+		}
+	}
+}
+
+func TestLoadHARErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid json": "{",
+		"no log":       "{}",
+	} {
+		if _, err := loadHAR(writeHAR(t, content)); err == nil {
+			t.Errorf("loadHAR() of %s succeeded, want error", name)
+		}
+	}
+
+	if _, err := loadHAR(filepath.Join(t.TempDir(), "missing.har")); err == nil {
+		t.Error("loadHAR() of a missing file succeeded, want error")
+	}
+}
+
+func TestHarRecordingConcurrentEvents(t *testing.T) {
+	sm := &SiteManager{har: &harRecording{}}
+
+	var wg sync.WaitGroup
+	// StartHAR, SaveHAR and StopHAR run on the goroutine of the actions
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			sm.har.set(&harRecorder{pending: make(map[network.RequestID]*harEntry)})
+			if recorder := sm.har.current(); recorder != nil {
+				recorder.log()
+			}
+			sm.har.set(nil)
+		}
+	}()
+
+	// the network events arrive on the goroutines of chromedp
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				id := fmt.Sprintf("%d-%d", n, i)
+				sm.onNetworkEvent(context.Background(), requestSent(id, "GET", "https://example.com/", 0))
+				sm.onNetworkEvent(context.Background(), responseReceived(id, 200, "text/html", time.Millisecond))
+				sm.onNetworkEvent(context.Background(), &network.EventLoadingFailed{RequestID: network.RequestID(id), Timestamp: monotonic(2 * time.Millisecond)})
+			}
+		}(n)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,20 @@
+package base
+
+// NewIsolatedContext creates a SiteManager on a new incognito browser context of the same chrome process,
+// it has its own cookies, storage and cache, so two users can be logged in at the same time.
+// It has the device and timeout of the SiteManager but not its state file, restore a state by RestoreState if needed.
+// Its Cancel closes only the context, Cancel of the SiteManager closes its isolated contexts too.
+func (sm *SiteManager) NewIsolatedContext() (*SiteManager, error) {
+	c := sm.config
+	c.stateFile = ""
+
+	isolated, err := sm.newIsolated(c)
+	if err != nil {
+		return nil, err
+	}
+
+	isolated.errorHandler = sm.errorHandler
+	sm.isolated = append(sm.isolated, isolated)
+
+	return isolated, nil
+}
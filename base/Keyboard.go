@@ -0,0 +1,345 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// modifierKeys are the modifier keys by their key name, with the key codes the page sees for them
+var modifierKeys = map[string]struct {
+	modifier input.Modifier
+	keyCode  int64
+}{
+	"Alt":     {input.ModifierAlt, 18},
+	"Control": {input.ModifierCtrl, 17},
+	"Meta":    {input.ModifierMeta, 91},
+	"Shift":   {input.ModifierShift, 16},
+}
+
+// keyAliases are the other names of the keys accepted by Press
+var keyAliases = map[string]string{
+	"Ctrl":    "Control",
+	"Cmd":     "Meta",
+	"Command": "Meta",
+	"Option":  "Alt",
+	"Esc":     "Escape",
+	"Return":  "Enter",
+	"Del":     "Delete",
+	"Up":      "ArrowUp",
+	"Down":    "ArrowDown",
+	"Left":    "ArrowLeft",
+	"Right":   "ArrowRight",
+}
+
+var (
+	keyTableOnce sync.Once
+	// keyNames finds the runes of the kb key table by the key value ("Enter", "a") or the key code ("KeyA", "ControlLeft")
+	keyNames map[string]rune
+	// shiftedKeys are the runes typed by the key code with the shift key held
+	shiftedKeys map[string]rune
+)
+
+func buildKeyTable() {
+	keyNames = make(map[string]rune)
+	shiftedKeys = make(map[string]rune)
+
+	for r, k := range kb.Keys {
+		if k.Shift {
+			shiftedKeys[k.Code] = r
+		}
+
+		for _, name := range []string{k.Key, k.Code} {
+			// the unshifted rune wins, the map is iterated in random order
+			if previous, ok := keyNames[name]; ok && !kb.Keys[previous].Shift {
+				continue
+			}
+			keyNames[name] = r
+		}
+	}
+}
+
+// keyboardState keeps the modifiers held down by HoldModifier, they are added to every key and chord until ReleaseModifier
+type keyboardState struct {
+	mu        sync.Mutex
+	modifiers input.Modifier
+}
+
+func (ks *keyboardState) held() input.Modifier {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	return ks.modifiers
+}
+
+func (ks *keyboardState) set(modifier input.Modifier, down bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if down {
+		ks.modifiers |= modifier
+	} else {
+		ks.modifiers &^= modifier
+	}
+}
+
+func (ks *keyboardState) clear() {
+	ks.set(^input.Modifier(0), false)
+}
+
+// Press presses a key or a chord of modifiers and a key, like "Enter", "Control+A", "Shift+Tab" or "Meta+Enter",
+// the modifiers are pressed in order, then released in reverse order. The keys are named by their DOM key value or code,
+// Ctrl, Cmd, Option and Esc are accepted too, a "+" key is written as "Control++".
+func (sm *SiteManager) Press(chord string, timeoutSec int64, handleError bool) error {
+	keys, err := parseChord(chord)
+	if err != nil {
+		sm.Error(err, handleError)
+		return err
+	}
+
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		modifiers := sm.keyboard.held()
+
+		var up []*input.DispatchKeyEventParams
+		for _, name := range keys[:len(keys)-1] {
+			mk := modifierKeys[name]
+			modifiers |= mk.modifier
+			down, release := modifierEvents(name, modifiers)
+			if err := down.Do(ctx); err != nil {
+				return err
+			}
+			up = append([]*input.DispatchKeyEventParams{release}, up...)
+		}
+
+		last := keys[len(keys)-1]
+		var events []*input.DispatchKeyEventParams
+		if _, ok := modifierKeys[last]; ok {
+			down, release := modifierEvents(last, modifiers|modifierKeys[last].modifier)
+			events = []*input.DispatchKeyEventParams{down, release}
+		} else {
+			events = keyEvents(keyNames[last], modifiers, false)
+		}
+
+		for _, ev := range append(events, up...) {
+			if err := ev.Do(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("Press", []interface{}{chord}, action)
+		return nil
+	}
+
+	err = sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// Type types the text into the focused element key by key, waiting perCharDelay between the keys.
+// The characters missing from the keyboard table (like the non latin letters) are inserted as text, without key events.
+func (sm *SiteManager) Type(text string, perCharDelay time.Duration, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		for i, r := range []rune(text) {
+			if i > 0 && perCharDelay > 0 {
+				if err := sleep(ctx, perCharDelay); err != nil {
+					return err
+				}
+			}
+
+			if r == '\n' {
+				r = '\r'
+			}
+			if _, ok := kb.Keys[r]; !ok {
+				if err := input.InsertText(string(r)).Do(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+
+			for _, ev := range keyEvents(r, sm.keyboard.held(), true) {
+				if err := ev.Do(ctx); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("Type", []interface{}{text, perCharDelay}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// HoldModifier presses the modifier key (Alt, Control, Meta or Shift) down and keeps it held for the next keys and chords, until ReleaseModifier
+func (sm *SiteManager) HoldModifier(name string, timeoutSec int64, handleError bool) error {
+	return sm.modifierAction("HoldModifier", name, true, timeoutSec, handleError)
+}
+
+// ReleaseModifier releases the modifier key held by HoldModifier
+func (sm *SiteManager) ReleaseModifier(name string, timeoutSec int64, handleError bool) error {
+	return sm.modifierAction("ReleaseModifier", name, false, timeoutSec, handleError)
+}
+
+func (sm *SiteManager) modifierAction(method string, name string, down bool, timeoutSec int64, handleError bool) error {
+	key := keyName(name)
+	mk, ok := modifierKeys[key]
+	if !ok {
+		err := fmt.Errorf("%s is not a modifier key, use Alt, Control, Meta or Shift", name)
+		sm.Error(err, handleError)
+		return err
+	}
+
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		modifiers := sm.keyboard.held()
+		if down {
+			modifiers |= mk.modifier
+		} else {
+			modifiers &^= mk.modifier
+		}
+
+		press, release := modifierEvents(key, modifiers)
+		ev := release
+		if down {
+			ev = press
+		}
+		if err := ev.Do(ctx); err != nil {
+			return err
+		}
+		sm.keyboard.set(mk.modifier, down)
+
+		return nil
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction(method, []interface{}{key}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// parseChord splits the chord into key names, every key but the last one has to be a modifier
+func parseChord(chord string) ([]string, error) {
+	parts := strings.Split(chord, "+")
+	if strings.HasSuffix(chord, "+") {
+		for len(parts) > 0 && parts[len(parts)-1] == "" {
+			parts = parts[:len(parts)-1]
+		}
+		parts = append(parts, "+")
+	}
+
+	keyTableOnce.Do(buildKeyTable)
+
+	var keys []string
+	for i, part := range parts {
+		name := keyName(strings.TrimSpace(part))
+		if name == "" {
+			return nil, fmt.Errorf("invalid key chord %q", chord)
+		}
+
+		_, modifier := modifierKeys[name]
+		if i < len(parts)-1 && !modifier {
+			return nil, fmt.Errorf("%s is not a modifier key in the chord %q", part, chord)
+		}
+		if _, ok := keyNames[name]; !ok && !modifier {
+			return nil, fmt.Errorf("unknown key %s in the chord %q", part, chord)
+		}
+
+		keys = append(keys, name)
+	}
+
+	return keys, nil
+}
+
+func keyName(name string) string {
+	if alias, ok := keyAliases[name]; ok {
+		return alias
+	}
+
+	return name
+}
+
+// modifierEvents returns the key down and key up events of the modifier key
+func modifierEvents(name string, modifiers input.Modifier) (*input.DispatchKeyEventParams, *input.DispatchKeyEventParams) {
+	keyTableOnce.Do(buildKeyTable)
+
+	k := kb.Keys[keyNames[name]]
+	down := &input.DispatchKeyEventParams{
+		Type:                  input.KeyDown,
+		Key:                   k.Key,
+		Code:                  k.Code,
+		WindowsVirtualKeyCode: modifierKeys[name].keyCode,
+		NativeVirtualKeyCode:  modifierKeys[name].keyCode,
+		Modifiers:             modifiers,
+	}
+	up := *down
+	up.Type = input.KeyUp
+	// the released modifier is not held in its key up event
+	up.Modifiers = modifiers &^ modifierKeys[name].modifier
+
+	return down, &up
+}
+
+// keyEvents returns the key down, char and key up events of the rune with the modifiers held,
+// the char event is sent only if the key types text: not with Control, Alt or Meta held.
+// Typing adds the shift the rune needs ("A", "!"), a chord has only the modifiers written in it.
+func keyEvents(r rune, modifiers input.Modifier, typing bool) []*input.DispatchKeyEventParams {
+	keyTableOnce.Do(buildKeyTable)
+
+	k := kb.Keys[r]
+	if modifiers&input.ModifierShift != 0 && unicode.IsPrint(r) {
+		if shifted, ok := shiftedKeys[k.Code]; ok {
+			r, k = shifted, kb.Keys[shifted]
+		}
+	}
+	if typing && k.Shift {
+		modifiers |= input.ModifierShift
+	}
+
+	down := input.DispatchKeyEventParams{
+		Type:                  input.KeyDown,
+		Key:                   k.Key,
+		Code:                  k.Code,
+		WindowsVirtualKeyCode: k.Windows,
+		NativeVirtualKeyCode:  k.Native,
+		Modifiers:             modifiers,
+	}
+	if goruntime.GOOS == "darwin" {
+		down.NativeVirtualKeyCode = 0
+	}
+	up := down
+	up.Type = input.KeyUp
+
+	if !k.Print || modifiers&(input.ModifierCtrl|input.ModifierAlt|input.ModifierMeta) != 0 {
+		return []*input.DispatchKeyEventParams{&down, &up}
+	}
+
+	char := down
+	char.Type = input.KeyChar
+	char.Text = k.Text
+	char.UnmodifiedText = k.Unmodified
+	// the char events of the printable keys have the rune as key code, like kb.Encode sends them
+	char.WindowsVirtualKeyCode = int64(r)
+	char.NativeVirtualKeyCode = int64(r)
+
+	return []*input.DispatchKeyEventParams{&down, &char, &up}
+}
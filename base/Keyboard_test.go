@@ -0,0 +1,118 @@
+package base
+
+import (
+	"github.com/chromedp/cdproto/input"
+	"reflect"
+	"testing"
+)
+
+func TestParseChord(t *testing.T) {
+	tests := []struct {
+		chord   string
+		want    []string
+		wantErr bool
+	}{
+		{"Enter", []string{"Enter"}, false},
+		{"a", []string{"a"}, false},
+		{"Control+A", []string{"Control", "A"}, false},
+		{"Ctrl+Shift+k", []string{"Control", "Shift", "k"}, false},
+		{"Cmd + Enter", []string{"Meta", "Enter"}, false},
+		{"Esc", []string{"Escape"}, false},
+		{"Shift+Tab", []string{"Shift", "Tab"}, false},
+		{"Control++", []string{"Control", "+"}, false},
+		{"Alt+Shift", []string{"Alt", "Shift"}, false},
+		{"KeyA", []string{"KeyA"}, false},
+		{"", nil, true},
+		{"Control++A", nil, true},
+		{"A+Control", nil, true},
+		{"Control+Nope", nil, true},
+		{"Control+", []string{"Control", "+"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chord, func(t *testing.T) {
+			got, err := parseChord(tt.chord)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChord(%q) error = %v, wantErr %v", tt.chord, err, tt.wantErr)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseChord(%q) = %q, want %q", tt.chord, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyEvents(t *testing.T) {
+	keyTableOnce.Do(buildKeyTable)
+
+	tests := []struct {
+		name          string
+		r             rune
+		modifiers     input.Modifier
+		typing        bool
+		wantKey       string
+		wantText      string
+		wantModifiers input.Modifier
+	}{
+		{"letter", 'a', 0, true, "a", "a", 0},
+		{"typed capital adds shift", 'A', 0, true, "A", "A", input.ModifierShift},
+		{"typed symbol adds shift", '!', 0, true, "!", "!", input.ModifierShift},
+		{"held shift types the shifted rune", 'a', input.ModifierShift, true, "A", "A", input.ModifierShift},
+		{"chord has no char event", 'a', input.ModifierCtrl, false, "a", "", input.ModifierCtrl},
+		{"chord of a capital adds no shift", keyNames["A"], input.ModifierCtrl, false, "A", "", input.ModifierCtrl},
+		{"chord with alt", 'x', input.ModifierAlt, false, "x", "", input.ModifierAlt},
+		{"not printable", keyNames["Tab"], 0, false, "Tab", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := keyEvents(tt.r, tt.modifiers, tt.typing)
+
+			wantTypes := []input.KeyType{input.KeyDown, input.KeyUp}
+			if tt.wantText != "" {
+				wantTypes = []input.KeyType{input.KeyDown, input.KeyChar, input.KeyUp}
+			}
+			if len(events) != len(wantTypes) {
+				t.Fatalf("%d events, want %d", len(events), len(wantTypes))
+			}
+
+			for i, ev := range events {
+				if ev.Type != wantTypes[i] || ev.Key != tt.wantKey || ev.Modifiers != tt.wantModifiers {
+					t.Errorf("event %d = %s %q modifiers %d, want %s %q modifiers %d", i, ev.Type, ev.Key, ev.Modifiers, wantTypes[i], tt.wantKey, tt.wantModifiers)
+				}
+			}
+			if tt.wantText != "" && events[1].Text != tt.wantText {
+				t.Errorf("char event text = %q, want %q", events[1].Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestModifierEvents(t *testing.T) {
+	down, up := modifierEvents("Control", input.ModifierCtrl|input.ModifierShift)
+
+	if down.Type != input.KeyDown || down.Key != "Control" || down.WindowsVirtualKeyCode != 17 || down.Modifiers != input.ModifierCtrl|input.ModifierShift {
+		t.Errorf("key down = %+v", down)
+	}
+	// the released modifier is not held in its own key up event
+	if up.Type != input.KeyUp || up.Modifiers != input.ModifierShift {
+		t.Errorf("key up = %+v", up)
+	}
+}
+
+func TestKeyboardState(t *testing.T) {
+	ks := &keyboardState{}
+
+	ks.set(input.ModifierShift, true)
+	ks.set(input.ModifierCtrl, true)
+	ks.set(input.ModifierShift, false)
+	if ks.held() != input.ModifierCtrl {
+		t.Errorf("held() = %d, want control", ks.held())
+	}
+
+	ks.set(input.ModifierAlt, true)
+	ks.clear()
+	if ks.held() != 0 {
+		t.Errorf("held() after clear() = %d, want none", ks.held())
+	}
+}
@@ -0,0 +1,281 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"math"
+)
+
+type DragMode int
+
+const (
+	// DragMouse presses the left button on the source, moves the mouse to the target and releases it, for the drag libraries listening on the mouse events
+	DragMouse DragMode = iota
+	// DragHTML5 fires the dragstart, dragenter, dragover, drop and dragend events of the html5 drag and drop api, the browser does not start them from the mouse events
+	DragHTML5
+)
+
+// dragSteps is the count of mouse moves between the source and the target, the drag libraries wait for some moves before starting to drag
+const dragSteps = 10
+
+// html5DragJS fires the events of dropping the element on the target, it returns the error if the drag was cancelled or the drop was not accepted
+const html5DragJS = `function(target) {
+	var data = new DataTransfer();
+	var fire = function(el, type) {
+		var r = el.getBoundingClientRect();
+		return el.dispatchEvent(new DragEvent(type, {
+			bubbles: true,
+			cancelable: true,
+			dataTransfer: data,
+			clientX: r.left + r.width / 2,
+			clientY: r.top + r.height / 2
+		}));
+	};
+
+	if (!fire(this, 'dragstart')) {
+		return 'the dragstart event was cancelled';
+	}
+	fire(target, 'dragenter');
+	// the target accepts the drop by cancelling the dragover event
+	var accepted = !fire(target, 'dragover');
+	if (accepted) {
+		fire(target, 'drop');
+	}
+	fire(this, 'dragend');
+
+	return accepted ? '' : 'the target did not accept the drop';
+}`
+
+// Hover moves the mouse over the middle of the element, the page gets the mouseover and mousemove events
+func (sm *SiteManager) Hover(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, hoverObject, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("Hover", []interface{}{selector}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// RightClick clicks the middle of the element by the right button, it opens the context menu of the page
+func (sm *SiteManager) RightClick(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, rightClickObject, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("RightClick", []interface{}{selector}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// ClickAt clicks the element at the offset from its top left corner, like a point of a canvas or a slider
+func (sm *SiteManager) ClickAt(selector string, offsetX float64, offsetY float64, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return clickObjectAt(ctx, el, offsetX, offsetY)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ClickAt", []interface{}{selector, offsetX, offsetY}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// MouseMove moves the mouse to the point of the viewport without pressing any button
+func (sm *SiteManager) MouseMove(x float64, y float64, timeoutSec int64, handleError bool) error {
+	action := chromedp.MouseEvent(input.MouseMoved, x, y, chromedp.ButtonNone)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("MouseMove", []interface{}{x, y}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// DragAndDrop drags the element of the source selector onto the element of the target selector
+func (sm *SiteManager) DragAndDrop(sourceSelector string, targetSelector string, mode DragMode, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		source, err := resolveNode(ctx, sourceSelector, options...)
+		if err != nil {
+			return err
+		}
+		target, err := resolveNode(ctx, targetSelector, options...)
+		if err != nil {
+			return err
+		}
+
+		if mode == DragHTML5 {
+			return dragHTML5(ctx, source, target)
+		}
+
+		return dragMouse(ctx, source, target)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("DragAndDrop", []interface{}{sourceSelector, targetSelector, mode}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (f *FrameScope) Hover(selector string, timeoutSec int64, handleError bool) error {
+	return f.do("Hover", []interface{}{selector}, f.elementAction(selector, hoverObject), timeoutSec, handleError)
+}
+
+func (f *FrameScope) RightClick(selector string, timeoutSec int64, handleError bool) error {
+	return f.do("RightClick", []interface{}{selector}, f.elementAction(selector, rightClickObject), timeoutSec, handleError)
+}
+
+func (f *FrameScope) ClickAt(selector string, offsetX float64, offsetY float64, timeoutSec int64, handleError bool) error {
+	action := f.elementAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return clickObjectAt(ctx, el, offsetX, offsetY)
+	})
+
+	return f.do("ClickAt", []interface{}{selector, offsetX, offsetY}, action, timeoutSec, handleError)
+}
+
+// resolveNode waits for the first node of the selector in the tab and returns its javascript object
+func resolveNode(ctx context.Context, selector string, options ...chromedp.QueryOption) (*runtime.RemoteObject, error) {
+	var el *runtime.RemoteObject
+	err := nodeAction(selector, func(ctx context.Context, obj *runtime.RemoteObject) error {
+		el = obj
+		return nil
+	}, options...).Do(ctx)
+
+	return el, err
+}
+
+// elementQuad scrolls the element into view and returns the first quad of its content box
+func elementQuad(ctx context.Context, el *runtime.RemoteObject) (dom.Quad, error) {
+	if err := callOn(ctx, el, scrollIntoViewJS, nil); err != nil {
+		return nil, err
+	}
+
+	quads, err := dom.GetContentQuads().WithObjectID(el.ObjectID).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(quads) == 0 || len(quads[0]) < 2 || len(quads[0])%2 != 0 {
+		return nil, chromedp.ErrInvalidDimensions
+	}
+
+	return quads[0], nil
+}
+
+// elementCenter scrolls the element into view and returns its middle in the coordinates of the viewport
+func elementCenter(ctx context.Context, el *runtime.RemoteObject) (float64, float64, error) {
+	quad, err := elementQuad(ctx, el)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return quadsCenter([]dom.Quad{quad})
+}
+
+func hoverObject(ctx context.Context, el *runtime.RemoteObject) error {
+	x, y, err := elementCenter(ctx, el)
+	if err != nil {
+		return err
+	}
+
+	return chromedp.MouseEvent(input.MouseMoved, x, y, chromedp.ButtonNone).Do(ctx)
+}
+
+func rightClickObject(ctx context.Context, el *runtime.RemoteObject) error {
+	x, y, err := elementCenter(ctx, el)
+	if err != nil {
+		return err
+	}
+
+	return chromedp.MouseClickXY(x, y, chromedp.ButtonRight).Do(ctx)
+}
+
+func clickObjectAt(ctx context.Context, el *runtime.RemoteObject, offsetX float64, offsetY float64) error {
+	quad, err := elementQuad(ctx, el)
+	if err != nil {
+		return err
+	}
+
+	left, top := math.Inf(1), math.Inf(1)
+	for i := 0; i < len(quad); i += 2 {
+		left = math.Min(left, quad[i])
+		top = math.Min(top, quad[i+1])
+	}
+
+	return chromedp.MouseClickXY(left+offsetX, top+offsetY).Do(ctx)
+}
+
+func dragMouse(ctx context.Context, source *runtime.RemoteObject, target *runtime.RemoteObject) error {
+	fromX, fromY, err := elementCenter(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	steps := []chromedp.Action{
+		chromedp.MouseEvent(input.MouseMoved, fromX, fromY, chromedp.ButtonNone),
+		chromedp.MouseEvent(input.MousePressed, fromX, fromY, chromedp.ButtonLeft, chromedp.ClickCount(1)),
+	}
+	if err := chromedp.Tasks(steps).Do(ctx); err != nil {
+		return err
+	}
+
+	// the target is measured after pressing, the page may move it when the drag starts
+	toX, toY, err := elementCenter(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= dragSteps; i++ {
+		x := fromX + (toX-fromX)*float64(i)/dragSteps
+		y := fromY + (toY-fromY)*float64(i)/dragSteps
+		move := input.DispatchMouseEvent(input.MouseMoved, x, y).WithButton(input.Left).WithButtons(1)
+		if err := move.Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	return chromedp.MouseEvent(input.MouseReleased, toX, toY, chromedp.ButtonLeft, chromedp.ClickCount(1)).Do(ctx)
+}
+
+func dragHTML5(ctx context.Context, source *runtime.RemoteObject, target *runtime.RemoteObject) error {
+	res, exp, err := runtime.CallFunctionOn(html5DragJS).
+		WithObjectID(source.ObjectID).
+		WithArguments([]*runtime.CallArgument{{ObjectID: target.ObjectID}}).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exp != nil {
+		return exp
+	}
+
+	var message string
+	if err := decodeRemoteValue(res, &message); err != nil {
+		return err
+	}
+	if message != "" {
+		return fmt.Errorf("could not drag and drop: %s", message)
+	}
+
+	return nil
+}
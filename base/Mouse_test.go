@@ -0,0 +1,128 @@
+package base
+
+import (
+	"encoding/json"
+	"github.com/chromedp/cdproto/runtime"
+	"strings"
+	"testing"
+)
+
+type mouseEvent struct {
+	Type    string  `json:"type"`
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Button  string  `json:"button"`
+	Buttons int64   `json:"buttons"`
+}
+
+// mouseEvents returns the mouse events dispatched into the fake tab in order
+func mouseEvents(t *testing.T, ft *fakeTab) []mouseEvent {
+	t.Helper()
+
+	var events []mouseEvent
+	for _, params := range ft.sent("Input.dispatchMouseEvent") {
+		var e mouseEvent
+		if err := json.Unmarshal([]byte(params), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	return events
+}
+
+func TestDragMouse(t *testing.T) {
+	ft := newFakeTab()
+	ft.answer("Runtime.callFunctionOn", `{"result": {"type": "undefined"}}`)
+	// the source is around (10, 10), the target around (110, 210)
+	ft.answer("DOM.getContentQuads", `{"quads": [[0, 0, 20, 0, 20, 20, 0, 20]]}`, `{"quads": [[100, 200, 120, 200, 120, 220, 100, 220]]}`)
+
+	if err := dragMouse(ft.context(), &runtime.RemoteObject{ObjectID: "source"}, &runtime.RemoteObject{ObjectID: "target"}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := mouseEvents(t, ft)
+	if len(events) != dragSteps+3 {
+		t.Fatalf("%d mouse events, want %d", len(events), dragSteps+3)
+	}
+
+	if e := events[0]; e.Type != "mouseMoved" || e.X != 10 || e.Y != 10 {
+		t.Errorf("first event = %+v, want moving onto the source", e)
+	}
+	if e := events[1]; e.Type != "mousePressed" || e.X != 10 || e.Y != 10 || e.Button != "left" {
+		t.Errorf("second event = %+v, want pressing on the source", e)
+	}
+	// the moves go in even steps, the last one is on the target, with the left button held
+	for i, e := range events[2 : 2+dragSteps] {
+		wantX, wantY := 10+float64(i+1)*10, 10+float64(i+1)*20
+		if e.Type != "mouseMoved" || e.X != wantX || e.Y != wantY || e.Button != "left" || e.Buttons != 1 {
+			t.Errorf("move %d = %+v, want (%v, %v) with the left button", i+1, e, wantX, wantY)
+		}
+	}
+	if e := events[len(events)-1]; e.Type != "mouseReleased" || e.X != 110 || e.Y != 210 || e.Button != "left" {
+		t.Errorf("last event = %+v, want releasing on the target", e)
+	}
+
+	// the target is measured after the press
+	if quads := ft.sent("DOM.getContentQuads"); len(quads) != 2 || quads[1] != `{"objectId":"target"}` {
+		t.Errorf("quads asked by %q", quads)
+	}
+}
+
+func TestClickObjectAt(t *testing.T) {
+	ft := newFakeTab()
+	ft.answer("Runtime.callFunctionOn", `{"result": {"type": "undefined"}}`)
+	// a rotated element, its top left is taken from the box of the corners
+	ft.answer("DOM.getContentQuads", `{"quads": [[50, 10, 90, 50, 50, 90, 10, 50]]}`)
+
+	if err := clickObjectAt(ft.context(), &runtime.RemoteObject{ObjectID: "1"}, 5, 15); err != nil {
+		t.Fatal(err)
+	}
+
+	events := mouseEvents(t, ft)
+	if len(events) != 2 || events[0].Type != "mousePressed" || events[1].Type != "mouseReleased" {
+		t.Fatalf("mouse events = %+v, want a click", events)
+	}
+	for _, e := range events {
+		if e.X != 15 || e.Y != 25 {
+			t.Errorf("%s at (%v, %v), want (15, 25)", e.Type, e.X, e.Y)
+		}
+	}
+}
+
+func TestDragHTML5(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		wantErr string
+	}{
+		{"dropped", remoteValue(`""`), ""},
+		{"not accepted", remoteValue(`"the target did not accept the drop"`), "could not drag and drop: the target did not accept the drop"},
+		{"thrown", `{"result": {"type": "object"}, "exceptionDetails": {"exceptionId": 1, "text": "Uncaught", "lineNumber": 0, "columnNumber": 0}}`, "Uncaught"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := newFakeTab()
+			ft.answer("Runtime.callFunctionOn", tt.answer)
+
+			err := dragHTML5(ft.context(), &runtime.RemoteObject{ObjectID: "source"}, &runtime.RemoteObject{ObjectID: "target"})
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("dragHTML5() error = %v, want %q", err, tt.wantErr)
+			}
+
+			var call struct {
+				ObjectID  string `json:"objectId"`
+				Arguments []struct {
+					ObjectID string `json:"objectId"`
+				} `json:"arguments"`
+			}
+			if err := json.Unmarshal([]byte(ft.sent("Runtime.callFunctionOn")[0]), &call); err != nil {
+				t.Fatal(err)
+			}
+			if call.ObjectID != "source" || len(call.Arguments) != 1 || call.Arguments[0].ObjectID != "target" {
+				t.Errorf("called on %+v, want the source with the target", call)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package base
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type InterceptAction int
+
+const (
+	// InterceptFulfill answers the request with the status, headers and body of the rule
+	InterceptFulfill InterceptAction = iota
+	// InterceptFail fails the request with a network error
+	InterceptFail
+	// InterceptContinue lets the request go to the network, useful together with Delay
+	InterceptContinue
+)
+
+type InterceptRule struct {
+	// URL is a glob pattern ('*' any characters, '?' one character) or a regular expression if Regex is set
+	URL    string
+	Regex  bool
+	Method string
+
+	Action  InterceptAction
+	Status  int64
+	Headers map[string]string
+	// Body is the content of the response, or BodyFile is read when the rule is added
+	Body        []byte
+	BodyFile    string
+	ErrorReason network.ErrorReason
+	// Delay is waited before the request is fulfilled, failed or continued
+	Delay time.Duration
+
+	re *regexp.Regexp
+}
+
+type interceptor struct {
+	mu     sync.RWMutex
+	rules  []InterceptRule
+	replay *harReplay
+}
+
+// add appends the rule, or replaces the rule of the same url pattern and method, so a group processed again does not add its rules twice
+func (i *interceptor) add(rule InterceptRule) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for n, r := range i.rules {
+		if r.URL == rule.URL && r.Regex == rule.Regex && strings.EqualFold(r.Method, rule.Method) {
+			i.rules[n] = rule
+			return
+		}
+	}
+
+	i.rules = append(i.rules, rule)
+}
+
+func (i *interceptor) clear() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.rules = nil
+	i.replay = nil
+}
+
+func (i *interceptor) setReplay(replay *harReplay) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.replay = replay
+}
+
+// match returns the first matching rule, the rules are checked in the order they were added
+func (i *interceptor) match(request *network.Request) (InterceptRule, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, rule := range i.rules {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, request.Method) {
+			continue
+		}
+		if rule.re.MatchString(request.URL) {
+			return rule, true
+		}
+	}
+
+	return InterceptRule{}, false
+}
+
+// action decides how the paused request goes on: by the first matching rule, from the replayed har, or to the network
+func (i *interceptor) action(e *fetch.EventRequestPaused) chromedp.Action {
+	if rule, ok := i.match(e.Request); ok {
+		return rule.respond(e.RequestID)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.replay != nil {
+		if entry, ok := i.replay.next(e.Request); ok {
+			return replayResponse(e.RequestID, entry)
+		}
+		// replaying runs offline
+		return fetch.FailRequest(e.RequestID, network.ErrorReasonInternetDisconnected)
+	}
+
+	return fetch.ContinueRequest(e.RequestID)
+}
+
+// Intercept adds a rule to answer, fail or delay the requests matching its url pattern and method,
+// a rule of the same url pattern and method added before is replaced
+func (sm *SiteManager) Intercept(rule InterceptRule, timeoutSec int64, handleError bool) error {
+	err := rule.prepare()
+	if err != nil {
+		sm.Error(err, handleError)
+		return err
+	}
+
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		sm.interceptor.add(rule)
+		return sm.enableFetch(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("Intercept", []interface{}{rule.Method, rule.URL}, action)
+		return nil
+	}
+
+	err = sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// ClearIntercepts removes every rule, stops the har replay and intercepting the requests
+func (sm *SiteManager) ClearIntercepts(timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		sm.interceptor.clear()
+		return fetch.Disable().Do(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ClearIntercepts", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// enableFetch pauses every request of the tab, the ones without matching rule are continued
+func (sm *SiteManager) enableFetch(ctx context.Context) error {
+	sm.listen("fetch", sm.onRequestPaused)
+
+	return fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: "*"}}).Do(ctx)
+}
+
+func (sm *SiteManager) onRequestPaused(ctx context.Context, ev interface{}) {
+	e, ok := ev.(*fetch.EventRequestPaused)
+	if !ok {
+		return
+	}
+
+	go func() {
+		if err := execute(ctx, sm.interceptor.action(e)); err != nil {
+			sm.logf("could not answer intercepted request %s: %v", e.Request.URL, err)
+		}
+	}()
+}
+
+func (rule *InterceptRule) prepare() error {
+	if rule.URL == "" {
+		return errors.New("intercept rule needs an url pattern")
+	}
+
+	pattern := rule.URL
+	if !rule.Regex {
+		pattern = globToRegexp(rule.URL)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid url pattern %q: %v", rule.URL, err)
+	}
+	rule.re = re
+
+	if rule.BodyFile != "" {
+		body, err := ioutil.ReadFile(rule.BodyFile)
+		if err != nil {
+			return err
+		}
+		rule.Body = body
+	}
+
+	if rule.Status == 0 {
+		rule.Status = 200
+	}
+
+	if rule.ErrorReason == "" {
+		rule.ErrorReason = network.ErrorReasonFailed
+	}
+
+	return nil
+}
+
+func (rule InterceptRule) respond(id fetch.RequestID) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if rule.Delay > 0 {
+			if err := chromedp.Sleep(rule.Delay).Do(ctx); err != nil {
+				return err
+			}
+		}
+
+		switch rule.Action {
+		case InterceptFail:
+			return fetch.FailRequest(id, rule.ErrorReason).Do(ctx)
+		case InterceptContinue:
+			return fetch.ContinueRequest(id).Do(ctx)
+		}
+
+		var headers []*fetch.HeaderEntry
+		for name, value := range rule.Headers {
+			headers = append(headers, &fetch.HeaderEntry{Name: name, Value: value})
+		}
+
+		return fetch.FulfillRequest(id, rule.Status).
+			WithResponseHeaders(headers).
+			WithBody(base64.StdEncoding.EncodeToString(rule.Body)).
+			Do(ctx)
+	})
+}
+
+// globToRegexp converts a glob pattern to an anchored regular expression
+func globToRegexp(glob string) string {
+	pattern := regexp.QuoteMeta(glob)
+	pattern = strings.Replace(pattern, `\*`, ".*", -1)
+	pattern = strings.Replace(pattern, `\?`, ".", -1)
+
+	return "^" + pattern + "$"
+}
@@ -0,0 +1,217 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		url   string
+		match bool
+	}{
+		{"https://example.com/api/*", "https://example.com/api/users?page=2", true},
+		{"https://example.com/api/*", "https://example.com/static/app.js", false},
+		{"*.png", "https://cdn.example.com/logo.png", true},
+		{"*.png", "https://cdn.example.com/logo.png?v=2", false},
+		{"https://example.com/user/?", "https://example.com/user/7", true},
+		{"https://example.com/user/?", "https://example.com/user/42", false},
+		{"https://example.com/a+b(1).json", "https://example.com/a+b(1).json", true},
+		{"https://example.com/a.json", "https://example.com/aXjson", false},
+		{"example.com", "https://example.com", false},
+	}
+
+	for _, tt := range tests {
+		re := regexp.MustCompile(globToRegexp(tt.glob))
+		if got := re.MatchString(tt.url); got != tt.match {
+			t.Errorf("glob %q on %q = %v, want %v", tt.glob, tt.url, got, tt.match)
+		}
+	}
+}
+
+func TestInterceptRulePrepare(t *testing.T) {
+	bodyFile := filepath.Join(t.TempDir(), "users.json")
+	if err := ioutil.WriteFile(bodyFile, []byte(`[{"id": 1}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		rule       InterceptRule
+		wantErr    bool
+		wantStatus int64
+		wantBody   string
+	}{
+		{"defaults", InterceptRule{URL: "*/api/*"}, false, 200, ""},
+		{"status kept", InterceptRule{URL: "*/api/*", Status: 404, Body: []byte("missing")}, false, 404, "missing"},
+		{"body file", InterceptRule{URL: "*/users", BodyFile: bodyFile}, false, 200, `[{"id": 1}]`},
+		{"regex", InterceptRule{URL: `/items/\d+$`, Regex: true}, false, 200, ""},
+		{"no url", InterceptRule{}, true, 0, ""},
+		{"invalid regex", InterceptRule{URL: "(", Regex: true}, true, 0, ""},
+		{"missing body file", InterceptRule{URL: "*", BodyFile: filepath.Join(t.TempDir(), "none")}, true, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			err := rule.prepare()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if rule.Status != tt.wantStatus || string(rule.Body) != tt.wantBody || rule.ErrorReason != network.ErrorReasonFailed || rule.re == nil {
+				t.Errorf("prepared rule = %+v", rule)
+			}
+		})
+	}
+}
+
+func TestInterceptorMatch(t *testing.T) {
+	i := &interceptor{}
+	for _, rule := range []InterceptRule{
+		{URL: "*/api/users", Method: "POST", Status: 201},
+		{URL: "*/api/*", Status: 200},
+		{URL: "*.png", Action: InterceptFail},
+	} {
+		if err := rule.prepare(); err != nil {
+			t.Fatal(err)
+		}
+		i.add(rule)
+	}
+
+	tests := []struct {
+		method     string
+		url        string
+		match      bool
+		wantStatus int64
+	}{
+		{"POST", "https://example.com/api/users", true, 201},
+		{"post", "https://example.com/api/users", true, 201},
+		{"GET", "https://example.com/api/users", true, 200},
+		{"GET", "https://example.com/logo.png", true, 200},
+		{"GET", "https://example.com/index.html", false, 0},
+	}
+
+	for _, tt := range tests {
+		rule, ok := i.match(&network.Request{Method: tt.method, URL: tt.url})
+		if ok != tt.match || rule.Status != tt.wantStatus {
+			t.Errorf("match(%s %s) = status %d, %v, want %d, %v", tt.method, tt.url, rule.Status, ok, tt.wantStatus, tt.match)
+		}
+	}
+
+	i.clear()
+	if _, ok := i.match(&network.Request{Method: "GET", URL: "https://example.com/api/users"}); ok {
+		t.Error("match() after clear() found a rule")
+	}
+}
+
+func TestInterceptorAction(t *testing.T) {
+	i := &interceptor{}
+	paused := &fetch.EventRequestPaused{RequestID: "1", Request: &network.Request{Method: "GET", URL: "https://example.com/"}}
+
+	if _, ok := i.action(paused).(*fetch.ContinueRequestParams); !ok {
+		t.Errorf("action() without rules = %T, want the request continued", i.action(paused))
+	}
+
+	i.setReplay(&harReplay{entries: nil})
+	if fail, ok := i.action(paused).(*fetch.FailRequestParams); !ok || fail.ErrorReason != network.ErrorReasonInternetDisconnected {
+		t.Errorf("action() of a request missing from the replay = %#v, want it failed offline", i.action(paused))
+	}
+}
+
+func TestInterceptorConcurrentAccess(t *testing.T) {
+	i := &interceptor{}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(2)
+		go func(n int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				rule := InterceptRule{URL: fmt.Sprintf("*/%d/%d", n, j)}
+				if err := rule.prepare(); err != nil {
+					t.Error(err)
+					return
+				}
+				i.add(rule)
+			}
+		}(n)
+		// the request paused events match the rules on the goroutines of chromedp
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				i.action(&fetch.EventRequestPaused{RequestID: "1", Request: &network.Request{Method: "GET", URL: "https://example.com/0/1"}})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(i.rules) != 200 {
+		t.Errorf("%d rules added, want 200", len(i.rules))
+	}
+}
+
+func TestInterceptorAddReplaces(t *testing.T) {
+	i := &interceptor{}
+	for _, rule := range []InterceptRule{
+		{URL: "*/api/*", Status: 200},
+		{URL: "*/api/*", Method: "POST", Status: 201},
+		{URL: ".*/api/.*", Regex: true, Status: 202},
+		{URL: "*/api/*", Status: 503},
+		{URL: "*/api/*", Method: "post", Status: 400},
+	} {
+		if err := rule.prepare(); err != nil {
+			t.Fatal(err)
+		}
+		i.add(rule)
+	}
+
+	if len(i.rules) != 3 {
+		t.Fatalf("%d rules, want 3", len(i.rules))
+	}
+	// the replaced rules keep their place
+	for n, want := range []int64{503, 400, 202} {
+		if i.rules[n].Status != want {
+			t.Errorf("rule %d has status %d, want %d", n, i.rules[n].Status, want)
+		}
+	}
+}
+
+func TestInterceptInGroupProcessedTwice(t *testing.T) {
+	sm := newTestManager()
+	// a browser context not started, the handlers can listen on it
+	ctx, cancel := chromedp.NewContext(context.Background())
+	defer cancel()
+	sm.tabs = newTabSet("main", ctx)
+	sm.Group("mock")
+	if err := sm.Intercept(InterceptRule{URL: "*/api/users", Status: 500}, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Intercept(InterceptRule{URL: "*/api/users", Status: 200}, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	sm.Group("")
+
+	// every processing of the group runs the recorded actions again, the browser is missing so only the rules are added
+	for n := 0; n < 2; n++ {
+		for _, ga := range sm.groupActions["mock"] {
+			ga.action.Do(context.Background())
+		}
+	}
+
+	rule, ok := sm.interceptor.match(&network.Request{Method: "GET", URL: "https://example.com/api/users"})
+	if len(sm.interceptor.rules) != 1 || !ok || rule.Status != 200 {
+		t.Errorf("%d rules after processing the group twice, matching status %d, want 1 rule of status 200", len(sm.interceptor.rules), rule.Status)
+	}
+}
@@ -0,0 +1,144 @@
+package base
+
+import (
+	"github.com/chromedp/chromedp"
+	"log"
+)
+
+// ManagerOption configures the SiteManager created by New (Option is the html option tag of the xpath builder)
+type ManagerOption func(c *config)
+
+type config struct {
+	device           chromedp.Device
+	timeoutSec       int64
+	headless         bool
+	ignoreCertErrors bool
+	chromePath       string
+	userDataDir      string
+	proxy            string
+	windowWidth      int
+	windowHeight     int
+	flags            map[string]interface{}
+	logf             func(format string, args ...interface{})
+	stateFile        string
+	// remoteURL is the websocket url of the running browser set by NewRemote
+	remoteURL string
+}
+
+func newConfig(opts ...ManagerOption) config {
+	c := config{
+		device:   PC,
+		headless: true,
+		flags:    make(map[string]interface{}),
+		logf:     log.Printf,
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// allocatorOptions returns the options of the locally started chrome
+func (c config) allocatorOptions() []chromedp.ExecAllocatorOption {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.NoDefaultBrowserCheck,
+		chromedp.Flag("headless", c.headless),
+		chromedp.Flag("ignore-certificate-errors", c.ignoreCertErrors),
+	)
+
+	if c.chromePath != "" {
+		opts = append(opts, chromedp.ExecPath(c.chromePath))
+	}
+	if c.userDataDir != "" {
+		opts = append(opts, chromedp.UserDataDir(c.userDataDir))
+	}
+	if c.proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(c.proxy))
+	}
+	if c.windowWidth > 0 && c.windowHeight > 0 {
+		opts = append(opts, chromedp.WindowSize(c.windowWidth, c.windowHeight))
+	}
+	for name, value := range c.flags {
+		opts = append(opts, chromedp.Flag(name, value))
+	}
+
+	return opts
+}
+
+// WithDevice sets the emulated device, PC by default
+func WithDevice(d chromedp.Device) ManagerOption {
+	return func(c *config) {
+		c.device = d
+	}
+}
+
+// WithTimeout sets the default timeout of the actions called with zero timeout
+func WithTimeout(timeoutSec int64) ManagerOption {
+	return func(c *config) {
+		c.timeoutSec = timeoutSec
+	}
+}
+
+// WithHeadless runs chrome without window, it is enabled by default
+func WithHeadless(headless bool) ManagerOption {
+	return func(c *config) {
+		c.headless = headless
+	}
+}
+
+func WithIgnoreCertErrors(ignore bool) ManagerOption {
+	return func(c *config) {
+		c.ignoreCertErrors = ignore
+	}
+}
+
+// WithChromePath sets the chrome binary to start instead of the one found on the system
+func WithChromePath(path string) ManagerOption {
+	return func(c *config) {
+		c.chromePath = path
+	}
+}
+
+// WithUserDataDir sets the profile directory of chrome, by default a temporary one is created
+func WithUserDataDir(dir string) ManagerOption {
+	return func(c *config) {
+		c.userDataDir = dir
+	}
+}
+
+// WithProxy sends the traffic of the browser through the proxy, like "http://127.0.0.1:8080" or "socks5://127.0.0.1:1080"
+func WithProxy(proxy string) ManagerOption {
+	return func(c *config) {
+		c.proxy = proxy
+	}
+}
+
+func WithWindowSize(width, height int) ManagerOption {
+	return func(c *config) {
+		c.windowWidth = width
+		c.windowHeight = height
+	}
+}
+
+// WithFlag adds a command line flag of chrome, the value is true for the flags without value, or false to remove a default one
+func WithFlag(name string, value interface{}) ManagerOption {
+	return func(c *config) {
+		c.flags[name] = value
+	}
+}
+
+// WithLogger sets the function the browser and the event listeners log through, log.Printf by default
+func WithLogger(logf func(format string, args ...interface{})) ManagerOption {
+	return func(c *config) {
+		c.logf = logf
+	}
+}
+
+// WithStateFile restores the browser state saved by SaveState after the browser started
+func WithStateFile(path string) ManagerOption {
+	return func(c *config) {
+		c.stateFile = path
+	}
+}
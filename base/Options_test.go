@@ -0,0 +1,76 @@
+package base
+
+import (
+	"github.com/chromedp/chromedp"
+	"testing"
+)
+
+func TestNewConfig(t *testing.T) {
+	logged := 0
+	c := newConfig(
+		WithDevice(SonyXPeriaXZPremium),
+		WithTimeout(30),
+		WithHeadless(false),
+		WithIgnoreCertErrors(true),
+		WithChromePath("/opt/chrome/chrome"),
+		WithUserDataDir("/tmp/profile"),
+		WithProxy("socks5://127.0.0.1:1080"),
+		WithWindowSize(1280, 720),
+		WithFlag("mute-audio", true),
+		WithFlag("disable-gpu", false),
+		WithLogger(func(format string, args ...interface{}) { logged++ }),
+		WithStateFile("state.json"),
+	)
+
+	if c.device.Device().Name != SonyXPeriaXZPremium.Name || c.timeoutSec != 30 || c.headless || !c.ignoreCertErrors {
+		t.Errorf("config = %+v", c)
+	}
+	if c.chromePath != "/opt/chrome/chrome" || c.userDataDir != "/tmp/profile" || c.proxy != "socks5://127.0.0.1:1080" || c.stateFile != "state.json" {
+		t.Errorf("config = %+v", c)
+	}
+	if c.windowWidth != 1280 || c.windowHeight != 720 || c.flags["mute-audio"] != true || c.flags["disable-gpu"] != false {
+		t.Errorf("config = %+v", c)
+	}
+	c.logf("%s", "x")
+	if logged != 1 {
+		t.Error("the logger of WithLogger is not used")
+	}
+
+	defaults := newConfig()
+	if defaults.device.Device().Name != PC.Name || !defaults.headless || defaults.timeoutSec != 0 || defaults.logf == nil || len(defaults.flags) != 0 {
+		t.Errorf("default config = %+v", defaults)
+	}
+}
+
+func TestAllocatorOptions(t *testing.T) {
+	// the defaults of chromedp, no default browser check, headless and certificate errors
+	base := len(chromedp.DefaultExecAllocatorOptions) + 3
+
+	tests := []struct {
+		name string
+		opts []ManagerOption
+		want int
+	}{
+		{"defaults", nil, base},
+		{"chrome path", []ManagerOption{WithChromePath("/usr/bin/chromium")}, base + 1},
+		{"profile and proxy", []ManagerOption{WithUserDataDir("/tmp/p"), WithProxy("http://127.0.0.1:8080")}, base + 2},
+		{"window size", []ManagerOption{WithWindowSize(800, 600)}, base + 1},
+		{"window size without height", []ManagerOption{WithWindowSize(800, 0)}, base},
+		{"flags", []ManagerOption{WithFlag("mute-audio", true), WithFlag("lang", "hu")}, base + 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(newConfig(tt.opts...).allocatorOptions()); got != tt.want {
+				t.Errorf("%d allocator options, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// the default options of chromedp are not changed by appending to them
+	before := len(chromedp.DefaultExecAllocatorOptions)
+	newConfig(WithProxy("http://127.0.0.1:8080")).allocatorOptions()
+	if len(chromedp.DefaultExecAllocatorOptions) != before {
+		t.Error("allocatorOptions() changed the default options of chromedp")
+	}
+}
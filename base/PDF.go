@@ -0,0 +1,116 @@
+package base
+
+import (
+	"context"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"io/ioutil"
+)
+
+// PaperSize is the size of the pdf pages in inches
+type PaperSize struct {
+	Width  float64
+	Height float64
+}
+
+var (
+	PaperLetter = PaperSize{8.5, 11}
+	PaperLegal  = PaperSize{8.5, 14}
+	PaperA3     = PaperSize{11.69, 16.54}
+	PaperA4     = PaperSize{8.27, 11.69}
+	PaperA5     = PaperSize{5.83, 8.27}
+)
+
+const defaultPDFMargin = 0.4
+
+// PDFMargins are the margins of the pdf pages in inches
+type PDFMargins struct {
+	Top    float64
+	Bottom float64
+	Left   float64
+	Right  float64
+}
+
+type PDFOptions struct {
+	// Paper is letter if it is not set
+	Paper PaperSize
+	// Margins are 0.4 inch on every side if they are not set
+	Margins   *PDFMargins
+	Landscape bool
+	// Scale of the page rendering from 0.1 to 2, 1 if it is not set
+	Scale float64
+	// PrintBackground prints the background colors and images of the page
+	PrintBackground bool
+	// HeaderTemplate and FooterTemplate are html printed on every page, the elements with the classes date, title, url,
+	// pageNumber and totalPages get the values. They need place in the margins, an empty one stays empty if the other is set.
+	HeaderTemplate string
+	FooterTemplate string
+	// PageRanges are the printed pages like "1-5, 8, 11-13", every page if it is empty
+	PageRanges string
+	// PreferCSSPageSize uses the size of the @page css rule of the page instead of the Paper
+	PreferCSSPageSize bool
+}
+
+// PrintToPDF prints the page into the pdf file by the options, the browser has to run headless for it
+func (sm *SiteManager) PrintToPDF(path string, opts PDFOptions, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		content, _, err := opts.params().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		return ioutil.WriteFile(path, content, 0644)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("PrintToPDF", []interface{}{path}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (opts PDFOptions) params() *page.PrintToPDFParams {
+	p := page.PrintToPDF().
+		WithLandscape(opts.Landscape).
+		WithPrintBackground(opts.PrintBackground).
+		WithPreferCSSPageSize(opts.PreferCSSPageSize)
+
+	if opts.Paper.Width > 0 && opts.Paper.Height > 0 {
+		p = p.WithPaperWidth(opts.Paper.Width).WithPaperHeight(opts.Paper.Height)
+	}
+
+	// the margins are always sent by the protocol, zero would mean no margin
+	margins := PDFMargins{defaultPDFMargin, defaultPDFMargin, defaultPDFMargin, defaultPDFMargin}
+	if opts.Margins != nil {
+		margins = *opts.Margins
+	}
+	p = p.WithMarginTop(margins.Top).
+		WithMarginBottom(margins.Bottom).
+		WithMarginLeft(margins.Left).
+		WithMarginRight(margins.Right)
+
+	if opts.Scale > 0 {
+		p = p.WithScale(opts.Scale)
+	}
+
+	if opts.HeaderTemplate != "" || opts.FooterTemplate != "" {
+		// chrome prints its own date and title into the template left empty
+		header, footer := opts.HeaderTemplate, opts.FooterTemplate
+		if header == "" {
+			header = "<span></span>"
+		}
+		if footer == "" {
+			footer = "<span></span>"
+		}
+		p = p.WithDisplayHeaderFooter(true).WithHeaderTemplate(header).WithFooterTemplate(footer)
+	}
+
+	if opts.PageRanges != "" {
+		p = p.WithPageRanges(opts.PageRanges)
+	}
+
+	return p
+}
@@ -0,0 +1,60 @@
+package base
+
+import (
+	"github.com/chromedp/cdproto/page"
+	"reflect"
+	"testing"
+)
+
+func TestPDFOptionsParams(t *testing.T) {
+	defaults := func() page.PrintToPDFParams {
+		return page.PrintToPDFParams{MarginTop: 0.4, MarginBottom: 0.4, MarginLeft: 0.4, MarginRight: 0.4}
+	}
+
+	tests := []struct {
+		name string
+		opts PDFOptions
+		want func() page.PrintToPDFParams
+	}{
+		{"defaults", PDFOptions{}, defaults},
+		{"paper and orientation", PDFOptions{Paper: PaperA4, Landscape: true, PrintBackground: true}, func() page.PrintToPDFParams {
+			p := defaults()
+			p.PaperWidth, p.PaperHeight = 8.27, 11.69
+			p.Landscape, p.PrintBackground = true, true
+			return p
+		}},
+		{"incomplete paper is ignored", PDFOptions{Paper: PaperSize{Width: 5}}, defaults},
+		{"zero margins", PDFOptions{Margins: &PDFMargins{}}, func() page.PrintToPDFParams {
+			return page.PrintToPDFParams{}
+		}},
+		{"custom margins", PDFOptions{Margins: &PDFMargins{Top: 1, Bottom: 0.5, Left: 0.25, Right: 0}}, func() page.PrintToPDFParams {
+			return page.PrintToPDFParams{MarginTop: 1, MarginBottom: 0.5, MarginLeft: 0.25}
+		}},
+		{"scale and pages", PDFOptions{Scale: 0.8, PageRanges: "1-3, 5", PreferCSSPageSize: true}, func() page.PrintToPDFParams {
+			p := defaults()
+			p.Scale, p.PageRanges, p.PreferCSSPageSize = 0.8, "1-3, 5", true
+			return p
+		}},
+		{"header only", PDFOptions{HeaderTemplate: `<span class="title"></span>`}, func() page.PrintToPDFParams {
+			p := defaults()
+			p.DisplayHeaderFooter = true
+			p.HeaderTemplate, p.FooterTemplate = `<span class="title"></span>`, "<span></span>"
+			return p
+		}},
+		{"footer only", PDFOptions{FooterTemplate: `<span class="pageNumber"></span>`}, func() page.PrintToPDFParams {
+			p := defaults()
+			p.DisplayHeaderFooter = true
+			p.HeaderTemplate, p.FooterTemplate = "<span></span>", `<span class="pageNumber"></span>`
+			return p
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := *tt.opts.params()
+			if want := tt.want(); !reflect.DeepEqual(got, want) {
+				t.Errorf("params() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
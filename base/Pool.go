@@ -0,0 +1,438 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"sync"
+	"time"
+)
+
+type PoolMode int
+
+const (
+	// PoolBrowsers starts a browser for every SiteManager of the pool, the SiteManager uses a tab of an own incognito browser context in it
+	PoolBrowsers PoolMode = iota
+	// PoolTargets starts one browser, every SiteManager gets a tab of an own incognito browser context in it
+	PoolTargets
+)
+
+// Pool hands out SiteManagers to goroutines, a SiteManager is used by one goroutine at a time.
+// The SiteManagers of the pool must not be cancelled, Close does that.
+type Pool struct {
+	mode PoolMode
+	// root is the browser of the PoolTargets mode
+	root *SiteManager
+	free chan *SiteManager
+
+	mu       sync.Mutex
+	managers []*SiteManager
+	// browsers are the browsers of the SiteManagers in PoolBrowsers mode
+	browsers map[*SiteManager]*SiteManager
+	// leased are the SiteManagers acquired and not released yet
+	leased map[*SiteManager]bool
+	closed bool
+
+	scenarioSetup func(sm *SiteManager)
+}
+
+// ScenarioResult is the outcome of a scenario run by the pool, Result is nil if the scenario could not be started
+type ScenarioResult struct {
+	Scenario string
+	Result   *GroupResult
+	Err      error
+}
+
+// NewPool starts size SiteManagers configured by the options
+func NewPool(size int, mode PoolMode, opts ...ManagerOption) (*Pool, error) {
+	if size < 1 {
+		return nil, errors.New("pool size must be at least 1")
+	}
+
+	p := &Pool{mode: mode, free: make(chan *SiteManager, size), browsers: make(map[*SiteManager]*SiteManager), leased: make(map[*SiteManager]bool)}
+
+	if mode == PoolTargets {
+		root, err := New(opts...)
+		if err != nil {
+			return nil, err
+		}
+		p.root = root
+	}
+
+	managers := make([]*SiteManager, size)
+	browsers := make([]*SiteManager, size)
+	errs := make([]error, size)
+
+	var wg sync.WaitGroup
+	for i := 0; i < size; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			managers[i], browsers[i], errs[i] = p.newManager(opts...)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, sm := range managers {
+		if errs[i] != nil {
+			continue
+		}
+		if browsers[i] != nil {
+			p.browsers[sm] = browsers[i]
+		}
+		p.managers = append(p.managers, sm)
+		p.free <- sm
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// newManager returns a SiteManager in a new browser context, and the browser started for it in PoolBrowsers mode
+func (p *Pool) newManager(opts ...ManagerOption) (*SiteManager, *SiteManager, error) {
+	if p.mode == PoolTargets {
+		sm, err := p.root.newIsolated(p.root.config)
+		return sm, nil, err
+	}
+
+	browser, err := New(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sm, err := browser.newIsolated(browser.config)
+	if err != nil {
+		browser.Cancel()
+		return nil, nil, err
+	}
+
+	return sm, browser, nil
+}
+
+// browserOf returns the SiteManager of the browser the SiteManager of the pool runs in
+func (p *Pool) browserOf(sm *SiteManager) *SiteManager {
+	if p.mode == PoolTargets {
+		return p.root
+	}
+
+	return p.browsers[sm]
+}
+
+func (p *Pool) Size() int {
+	return cap(p.free)
+}
+
+// Acquire waits for a free SiteManager, zero timeout waits without limit.
+// The default timeout of the SiteManager starts when it is acquired, not when the pool created it.
+func (p *Pool) Acquire(timeoutSec int64) (*SiteManager, error) {
+	var timeout <-chan time.Time
+	if timeoutSec > 0 {
+		timer := time.NewTimer(time.Duration(timeoutSec) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case sm, ok := <-p.free:
+		if !ok {
+			return nil, errors.New("pool is closed")
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.closed {
+			return nil, errors.New("pool is closed")
+		}
+		p.leased[sm] = true
+		sm.startTimeout()
+
+		return sm, nil
+	case <-timeout:
+		return nil, errors.New("no free SiteManager in the pool")
+	}
+}
+
+// Release gives back the SiteManager, the next user gets a new SiteManager in a new browser context of the same browser,
+// so the cookies, storage, cache and settings of the previous user are gone. If the browser context can not be created,
+// the SiteManager is reused: its recorded groups and settings are reset, the cookies, the cache and the storage of the open page are cleared,
+// but the storage of the other sites visited stays. A SiteManager not acquired from the pool, or released already, is not taken back.
+func (p *Pool) Release(sm *SiteManager) {
+	p.mu.Lock()
+	leased, closed := p.leased[sm], p.closed
+	delete(p.leased, sm)
+	browser := p.browserOf(sm)
+	p.mu.Unlock()
+
+	if !leased {
+		sm.logf("the SiteManager is not acquired from the pool, it is not released")
+		return
+	}
+	if closed {
+		sm.Cancel()
+		return
+	}
+
+	// the browser is not reached under the lock, a slow browser must not block the other users of the pool
+	if browser != nil {
+		fresh, err := browser.newIsolated(browser.config)
+		if err == nil {
+			// cancelling disposes the browser context, it is not done under the lock either
+			if !p.put(fresh, sm) {
+				fresh.Cancel()
+			}
+			sm.Cancel()
+			return
+		}
+		sm.logf("could not create a new browser context, reusing the old one: %v", err)
+	}
+
+	sm.reset()
+	if err := sm.DoTimeoutContext(0, false, fetch.Disable(), network.ClearBrowserCookies(), network.ClearBrowserCache(), clearPageStorage(), chromedp.Navigate("about:blank")); err != nil {
+		sm.logf("could not clean the released SiteManager: %v", err)
+	}
+
+	if !p.put(sm, sm) {
+		sm.Cancel()
+	}
+}
+
+// put makes the SiteManager free in the place of the released one, it returns false if the pool is closed already
+func (p *Pool) put(sm, released *SiteManager) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+
+	if sm != released {
+		p.replace(released, sm)
+	}
+	p.free <- sm
+
+	return true
+}
+
+func (p *Pool) replace(old, fresh *SiteManager) {
+	for i, sm := range p.managers {
+		if sm == old {
+			p.managers[i] = fresh
+			break
+		}
+	}
+
+	if browser, ok := p.browsers[old]; ok {
+		delete(p.browsers, old)
+		p.browsers[fresh] = browser
+	}
+}
+
+// clearPageStorage clears the local storage, session storage, indexed db, cache storage and service workers of the origin of the open page
+func clearPageStorage() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		frames, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		origin := frames.Frame.SecurityOrigin
+		if origin == "" || origin == "null" {
+			return nil
+		}
+
+		return storage.ClearDataForOrigin(origin, "all").Do(ctx)
+	})
+}
+
+// Close cancels every SiteManager of the pool, also the ones not released yet
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.free)
+
+	for _, sm := range p.managers {
+		sm.Cancel()
+	}
+	for _, browser := range p.browsers {
+		browser.Cancel()
+	}
+	if p.root != nil {
+		p.root.Cancel()
+	}
+}
+
+// SetScenarioSetup sets a function to configure the SiteManager before RunScenarios runs a scenario on it,
+// like SetFailureScreenshot or AddErrorHandler, the settings are reset when the SiteManager is released
+func (p *Pool) SetScenarioSetup(setup func(sm *SiteManager)) {
+	p.scenarioSetup = setup
+}
+
+// RunScenarios runs the scenarios on the SiteManagers of the pool, at most concurrency at the same time (the size of the pool if zero).
+// The results are in the order of the scenarios, and the groups are added to the report if it is not nil.
+func (p *Pool) RunScenarios(scenarios []Scenario, concurrency int, report *Report) []ScenarioResult {
+	if concurrency <= 0 || concurrency > p.Size() {
+		concurrency = p.Size()
+	}
+
+	results := make([]ScenarioResult, len(scenarios))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = p.runScenario(scenarios[i], report)
+			}
+		}()
+	}
+
+	for i := range scenarios {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func (p *Pool) runScenario(sc Scenario, report *Report) ScenarioResult {
+	sr := ScenarioResult{Scenario: sc.Name}
+
+	sm, err := p.Acquire(0)
+	if err != nil {
+		sr.Err = err
+		return sr
+	}
+	defer p.Release(sm)
+
+	if p.scenarioSetup != nil {
+		p.scenarioSetup(sm)
+	}
+
+	sm.SetReport(report)
+	sr.Result, sr.Err = sm.RunScenario(sc, false)
+
+	return sr
+}
+
+// reset forgets what the previous user of the SiteManager recorded and set
+func (sm *SiteManager) reset() {
+	sm.activeGroup = ""
+	sm.groupActions = make(map[string][]groupAction)
+	sm.nextStepName = ""
+	sm.report = nil
+	sm.failureScreenshot = false
+	sm.failOnConsoleError = false
+	sm.assertMode = AssertHard
+	sm.assertFailures = nil
+	sm.baselineDir = ""
+	sm.updateBaselines = false
+	sm.errorHandler = nil
+	sm.interceptor.clear()
+	sm.har.set(nil)
+	sm.emulate(sm.config.device)
+	sm.ClearConsole()
+	sm.dialogs.clear()
+	sm.downloads.clear()
+	sm.keyboard.clear()
+	if sm.tabs.active != sm.tabs.main {
+		if err := sm.useTab(sm.tabs.main); err != nil {
+			sm.logf("could not switch back to the main tab: %v", err)
+		}
+	}
+}
+
+// emulate sets the device emulated by the next groups, the touch events are enabled for the touch devices
+func (sm *SiteManager) emulate(d chromedp.Device) {
+	sm.info = d
+
+	sm.fixActions = []chromedp.Action{chromedp.EmulateViewport(d.Device().Width, d.Device().Height, viewportOptions(d)...)}
+}
+
+// viewportOptions emulate the scale, the mobile layout and the touch screen of the device
+func viewportOptions(d chromedp.Device) []chromedp.EmulateViewportOption {
+	info := d.Device()
+
+	var opts []chromedp.EmulateViewportOption
+	if info.Scale > 0 {
+		opts = append(opts, chromedp.EmulateScale(info.Scale))
+	}
+	if info.Mobile {
+		opts = append(opts, chromedp.EmulateMobile)
+	}
+	if info.Touch {
+		opts = append(opts, chromedp.EmulateTouch)
+	}
+
+	return opts
+}
+
+// newIsolated creates a SiteManager configured by c on a tab of a new incognito browser context in the browser of the SiteManager,
+// the cookies and storage of it are separated. Its Cancel closes the tab and disposes the browser context.
+func (sm *SiteManager) newIsolated(c config) (*SiteManager, error) {
+	dc := chromedp.FromContext(sm.tabCtx)
+	if dc == nil || dc.Browser == nil {
+		return nil, errors.New("the browser of the SiteManager is not started")
+	}
+	browser := dc.Browser
+
+	browserCtx, err := browserContext(sm.tabCtx)
+	if err != nil {
+		return nil, err
+	}
+	contextID, err := target.CreateBrowserContext().Do(browserCtx)
+	if err != nil {
+		return nil, fmt.Errorf("could not create browser context: %v", err)
+	}
+
+	isolated := &SiteManager{}
+	isolated.cancel = append(isolated.cancel, func() {
+		// the tab context is already cancelled, the browser is reached by a new one
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := target.DisposeBrowserContext(contextID).Do(cdp.WithExecutor(ctx, browser)); err != nil {
+			sm.logf("could not dispose browser context %s: %v", contextID, err)
+		}
+	})
+
+	targetID, err := target.CreateTarget("about:blank").WithBrowserContextID(contextID).Do(browserCtx)
+	if err != nil {
+		isolated.Cancel()
+		return nil, fmt.Errorf("could not open tab in browser context: %v", err)
+	}
+
+	ctx, cancel := chromedp.NewContext(sm.tabCtx, chromedp.WithTargetID(targetID))
+	isolated.cancel = append(isolated.cancel, cancel)
+
+	if err := chromedp.Run(ctx); err != nil {
+		isolated.Cancel()
+		return nil, fmt.Errorf("could not attach to tab of browser context: %v", err)
+	}
+
+	if err := isolated.setup(c, ctx); err != nil {
+		isolated.Cancel()
+		return nil, err
+	}
+
+	return isolated, nil
+}
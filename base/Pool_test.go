@@ -0,0 +1,212 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/device"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestManager prepares a SiteManager like setup, without starting a browser: its actions fail with errNotStarted
+func newTestManager() *SiteManager {
+	sm := &SiteManager{}
+	sm.config = newConfig(WithLogger(func(format string, args ...interface{}) {}))
+	sm.info = sm.config.device
+	sm.groupActions = make(map[string][]groupAction)
+	sm.interceptor = &interceptor{}
+	sm.har = &harRecording{}
+	sm.tabs = newTabSet("main", nil)
+	sm.console = newConsoleBuffer()
+	sm.dialogs = newDialogQueue()
+	sm.fileChooser = &fileChooserWaiter{}
+	sm.downloads = newDownloadQueue()
+	sm.keyboard = &keyboardState{}
+	sm.emulate(sm.info)
+
+	return sm
+}
+
+func newTestPool(size int) *Pool {
+	p := &Pool{mode: PoolBrowsers, free: make(chan *SiteManager, size), browsers: make(map[*SiteManager]*SiteManager), leased: make(map[*SiteManager]bool)}
+	for i := 0; i < size; i++ {
+		sm := newTestManager()
+		p.managers = append(p.managers, sm)
+		p.free <- sm
+	}
+
+	return p
+}
+
+func TestPoolRunScenarios(t *testing.T) {
+	p := newTestPool(3)
+	defer p.Close()
+
+	var mu sync.Mutex
+	setups := 0
+	p.SetScenarioSetup(func(sm *SiteManager) {
+		mu.Lock()
+		setups++
+		mu.Unlock()
+		sm.SetFailureScreenshot(true)
+		sm.SetDialogPolicy(DialogDismiss, "")
+	})
+
+	var scenarios []Scenario
+	for i := 0; i < 12; i++ {
+		scenarios = append(scenarios, Scenario{
+			Name:   fmt.Sprintf("scenario-%d", i),
+			Device: "pc",
+			Steps:  []ScenarioStep{{Action: "goto", URL: "https://example.com"}, {Action: "click", Selector: "#next"}},
+		})
+	}
+	scenarios = append(scenarios, Scenario{Name: "unknown device", Device: "toaster"})
+
+	report := NewReport("pool")
+	results := p.RunScenarios(scenarios, 0, report)
+
+	if len(results) != len(scenarios) {
+		t.Fatalf("%d results, want %d", len(results), len(scenarios))
+	}
+	for i, sr := range results[:12] {
+		if sr.Scenario != scenarios[i].Name {
+			t.Errorf("result %d is of %s, want %s", i, sr.Scenario, scenarios[i].Name)
+		}
+		// the managers have no browser, the first step fails and the second one is skipped
+		if sr.Result == nil || sr.Err == nil || len(sr.Result.Steps) != 2 || sr.Result.Steps[1].Status != StepSkipped {
+			t.Errorf("result of %s = %+v", sr.Scenario, sr)
+		}
+	}
+	if last := results[12]; last.Result != nil || last.Err == nil {
+		t.Errorf("result of the scenario with an unknown device = %+v, want an error only", last)
+	}
+
+	// the setup runs on every acquired SiteManager, the device is checked by RunScenario after it
+	if setups != 13 {
+		t.Errorf("the scenario setup ran %d times, want 13", setups)
+	}
+	if len(report.groups()) != 12 {
+		t.Errorf("%d groups in the report, want 12", len(report.groups()))
+	}
+
+	// every manager is back in the pool, reset
+	for i := 0; i < p.Size(); i++ {
+		sm, err := p.Acquire(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sm.groupActions) != 0 || sm.report != nil || sm.failureScreenshot || sm.dialogs.policy != DialogAccept {
+			t.Errorf("released manager is not reset: %d groups, report %v, failure screenshot %v, dialog policy %v",
+				len(sm.groupActions), sm.report, sm.failureScreenshot, sm.dialogs.policy)
+		}
+	}
+}
+
+func TestPoolAcquire(t *testing.T) {
+	p := newTestPool(1)
+
+	sm, err := p.Acquire(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Acquire(1); err == nil {
+		t.Error("Acquire() of an empty pool succeeded, want timeout")
+	}
+
+	p.Release(sm)
+	if _, err := p.Acquire(1); err != nil {
+		t.Errorf("Acquire() after Release() error = %v", err)
+	}
+
+	p.Close()
+	p.Close()
+	if _, err := p.Acquire(1); err == nil {
+		t.Error("Acquire() of a closed pool succeeded, want error")
+	}
+	// releasing into the closed pool cancels the manager
+	p.Release(sm)
+}
+
+func TestPoolReleaseOnce(t *testing.T) {
+	p := newTestPool(2)
+	defer p.Close()
+
+	sm, err := p.Acquire(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release(sm)
+	p.Release(sm)
+	// a SiteManager not acquired from the pool is not taken either
+	p.Release(newTestManager())
+
+	if len(p.free) != 2 {
+		t.Fatalf("%d free SiteManagers, want 2", len(p.free))
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.Acquire(1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.Acquire(1); err == nil {
+		t.Error("Acquire() returned a SiteManager released twice")
+	}
+}
+
+func TestPoolAcquireStartsTimeout(t *testing.T) {
+	p := newTestPool(1)
+	defer p.Close()
+
+	sm, err := p.Acquire(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm.tabCtx = context.Background()
+	sm.timeoutSec = 60
+	sm.deadline = time.Now().Add(-time.Second)
+	p.Release(sm)
+
+	// the budget of the session starts when the SiteManager is handed out, an idle one does not lose it
+	if sm, err = p.Acquire(0); err != nil {
+		t.Fatal(err)
+	}
+	if !sm.deadline.After(time.Now().Add(59 * time.Second)) {
+		t.Errorf("the acquired SiteManager has deadline %v, want a minute from now", sm.deadline)
+	}
+	if sm.ctx == nil || sm.ctx.Err() != nil {
+		t.Errorf("the context of the acquired SiteManager is done: %v", sm.ctx)
+	}
+}
+
+func TestViewportOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		device     chromedp.Device
+		wantScale  float64
+		wantMobile bool
+		wantTouch  bool
+	}{
+		{"mobile", SonyXPeriaXZPremium, 0.49, true, true},
+		{"desktop", device.Info{Name: "desktop", Width: 1280, Height: 720, Scale: 1}, 1, false, false},
+		{"touch laptop", device.Info{Name: "laptop", Width: 1366, Height: 768, Scale: 1.5, Touch: true}, 1.5, false, true},
+		{"no scale", device.Info{Name: "unscaled", Width: 800, Height: 600}, 1, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, ok := chromedp.EmulateViewport(1000, 5000, viewportOptions(tt.device)...).(chromedp.Tasks)
+			if !ok || len(tasks) != 2 {
+				t.Fatalf("EmulateViewport() = %#v", tasks)
+			}
+			metrics := tasks[0].(*emulation.SetDeviceMetricsOverrideParams)
+			touch := tasks[1].(*emulation.SetTouchEmulationEnabledParams)
+
+			if metrics.Width != 1000 || metrics.Height != 5000 || metrics.DeviceScaleFactor != tt.wantScale || metrics.Mobile != tt.wantMobile || touch.Enabled != tt.wantTouch {
+				t.Errorf("resized viewport = %+v, touch %v", metrics, touch.Enabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewRemote attaches to a running chrome through its devtools endpoint instead of starting one.
+// The url is the websocket url of the browser (ws://host:9222/devtools/browser/...), or the http address of the
+// debugging port (http://host:9222) to look it up. The options of the chrome process (headless, flags, ...) are not used,
+// Cancel closes only the tab opened by the SiteManager and leaves the browser running.
+func NewRemote(url string, opts ...ManagerOption) (*SiteManager, error) {
+	wsURL, err := browserWebSocketURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConfig(opts...)
+	c.remoteURL = wsURL
+
+	sm := &SiteManager{}
+	if err := sm.start(c); err != nil {
+		return nil, err
+	}
+
+	return sm, nil
+}
+
+// browserWebSocketURL returns the websocket url of the browser, the http url of the debugging port is resolved by /json/version
+func browserWebSocketURL(url string) (string, error) {
+	if strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://") {
+		return url, nil
+	}
+
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", fmt.Errorf("devtools url %q must start with ws://, wss://, http:// or https://", url)
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(url, "/") + "/json/version")
+	if err != nil {
+		return "", fmt.Errorf("could not reach the devtools endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("devtools endpoint %s answered %s", url, resp.Status)
+	}
+
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return "", fmt.Errorf("could not parse the devtools version of %s: %v", url, err)
+	}
+	if version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("devtools endpoint %s did not return the websocket url", url)
+	}
+
+	return version.WebSocketDebuggerURL, nil
+}
@@ -0,0 +1,53 @@
+package base
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBrowserWebSocketURL(t *testing.T) {
+	const wsURL = "ws://127.0.0.1:9222/devtools/browser/0b1c2d3e"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"Browser": "HeadlessChrome/80.0.3987.0", "webSocketDebuggerUrl": %q}`, wsURL)
+	})
+	mux.HandleFunc("/corrupt/json/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"webSocketDebuggerUrl": `)
+	})
+	mux.HandleFunc("/empty/json/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Browser": "HeadlessChrome/80.0.3987.0"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"websocket", wsURL, wsURL, false},
+		{"secure websocket", "wss://chrome.example.com/devtools/browser/1", "wss://chrome.example.com/devtools/browser/1", false},
+		{"debugging port", server.URL, wsURL, false},
+		{"trailing slash", server.URL + "/", wsURL, false},
+		{"not found", server.URL + "/missing", "", true},
+		{"corrupt answer", server.URL + "/corrupt", "", true},
+		{"no websocket url", server.URL + "/empty", "", true},
+		{"unknown scheme", "localhost:9222", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := browserWebSocketURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("browserWebSocketURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("browserWebSocketURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
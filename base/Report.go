@@ -0,0 +1,307 @@
+package base
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Report collects the results of the processed groups, one group is one suite, its steps are the test cases
+type Report struct {
+	Name   string
+	Start  time.Time
+	Groups []*GroupResult
+
+	// mu guards Groups, the managers of a pool add their results concurrently
+	mu sync.Mutex
+}
+
+func NewReport(name string) *Report {
+	return &Report{Name: name, Start: time.Now()}
+}
+
+func (r *Report) Add(gr *GroupResult) {
+	if gr == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Groups = append(r.Groups, gr)
+}
+
+// groups returns the results added until now
+func (r *Report) groups() []*GroupResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*GroupResult(nil), r.Groups...)
+}
+
+func (r *Report) Failed() bool {
+	for _, gr := range r.groups() {
+		if gr.Failed() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetReport makes GroupProcess add every group result to the report
+func (sm *SiteManager) SetReport(r *Report) {
+	sm.report = r
+}
+
+func (sm SiteManager) GetReport() *Report {
+	return sm.report
+}
+
+// attachScreenshot stores the screenshot on the step running right now, if there is any
+func (sm *SiteManager) attachScreenshot(shot []byte) {
+	if sm.runningStep == nil || len(shot) == 0 {
+		return
+	}
+
+	sm.runningStep.Screenshot = append([]byte(nil), shot...)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+	SystemErr string          `xml:"system-err,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (r *Report) WriteJUnit(path string) error {
+	suites := junitTestSuites{Name: r.Name}
+
+	var total time.Duration
+	for _, gr := range r.groups() {
+		suite := junitTestSuite{
+			Name:      gr.Group,
+			Time:      seconds(gr.Duration),
+			Timestamp: gr.Start.Format(time.RFC3339),
+		}
+
+		for _, step := range gr.Steps {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%02d %s", step.Index, step.Name),
+				ClassName: fmt.Sprintf("%s.%s", r.Name, gr.Group),
+				Time:      seconds(step.Duration),
+			}
+
+			switch step.Status {
+			case StepFailed:
+				tc.Failure = &junitFailure{Message: errorText(step.Err), Type: step.Method, Text: step.String()}
+				suite.Failures++
+			case StepSkipped:
+				tc.Skipped = &struct{}{}
+				suite.Skipped++
+			}
+
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		if gr.Err != nil && len(gr.FailedSteps()) == 0 {
+			suite.SystemErr = gr.Err.Error()
+		}
+
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		total += gr.Duration
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = seconds(total)
+
+	content, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append([]byte(xml.Header), content...), 0644)
+}
+
+type jsonReport struct {
+	Name   string      `json:"name"`
+	Start  time.Time   `json:"start"`
+	Failed bool        `json:"failed"`
+	Groups []jsonGroup `json:"groups"`
+}
+
+type jsonGroup struct {
+	Group      string     `json:"group"`
+	Start      time.Time  `json:"start"`
+	DurationMs int64      `json:"durationMs"`
+	Error      string     `json:"error,omitempty"`
+	Steps      []jsonStep `json:"steps"`
+}
+
+type jsonStep struct {
+	Index      int        `json:"index"`
+	Name       string     `json:"name"`
+	Method     string     `json:"method"`
+	Args       []string   `json:"args,omitempty"`
+	Status     StepStatus `json:"status"`
+	Start      time.Time  `json:"start"`
+	DurationMs int64      `json:"durationMs"`
+	Error      string     `json:"error,omitempty"`
+	Screenshot string     `json:"screenshot,omitempty"`
+}
+
+func (r *Report) WriteJSON(path string) error {
+	content, err := json.MarshalIndent(r.jsonReport(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+func (r *Report) jsonReport() jsonReport {
+	jr := jsonReport{Name: r.Name, Start: r.Start, Failed: r.Failed()}
+
+	for _, gr := range r.groups() {
+		jg := jsonGroup{
+			Group:      gr.Group,
+			Start:      gr.Start,
+			DurationMs: gr.Duration.Milliseconds(),
+			Error:      errorText(gr.Err),
+		}
+
+		for _, step := range gr.Steps {
+			js := jsonStep{
+				Index:      step.Index,
+				Name:       step.Name,
+				Method:     step.Method,
+				Status:     step.Status,
+				Start:      step.Start,
+				DurationMs: step.Duration.Milliseconds(),
+				Error:      errorText(step.Err),
+				Screenshot: dataURI(step.Screenshot),
+			}
+			for _, arg := range step.Args {
+				js.Args = append(js.Args, fmt.Sprintf("%v", arg))
+			}
+
+			jg.Steps = append(jg.Steps, js)
+		}
+
+		jr.Groups = append(jr.Groups, jg)
+	}
+
+	return jr
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"uri": func(s string) template.URL { return template.URL(s) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.passed { color: #2e7d32; }
+.failed { color: #c62828; }
+.skipped { color: #757575; }
+img { max-width: 640px; }
+</style>
+</head>
+<body>
+<h1>{{.Name}} <span class="{{if .Failed}}failed{{else}}passed{{end}}">{{if .Failed}}failed{{else}}passed{{end}}</span></h1>
+<p>started at {{.Start.Format "2006-01-02 15:04:05"}}</p>
+{{range .Groups}}
+<h2>{{.Group}} <small>{{.DurationMs}} ms</small></h2>
+{{if .Error}}<pre class="failed">{{.Error}}</pre>{{end}}
+<table>
+<tr><th>#</th><th>step</th><th>method</th><th>arguments</th><th>status</th><th>ms</th><th>error</th></tr>
+{{range .Steps}}
+<tr>
+<td>{{.Index}}</td><td>{{.Name}}</td><td>{{.Method}}</td><td>{{range .Args}}{{.}}<br>{{end}}</td>
+<td class="{{.Status}}">{{.Status}}</td><td>{{.DurationMs}}</td><td>{{if .Error}}<pre>{{.Error}}</pre>{{end}}</td>
+</tr>
+{{if .Screenshot}}<tr><td></td><td colspan="6"><img src="{{uri .Screenshot}}" alt="{{.Name}}"></td></tr>{{end}}
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes a single self-contained html file, the screenshots are embedded as data uris
+func (r *Report) WriteHTML(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := htmlReport.Execute(f, r.jsonReport()); err != nil {
+		f.Close()
+		return err
+	}
+
+	// a failing close can lose the end of the report, it is an error of the write too
+	return f.Close()
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+func dataURI(content []byte) string {
+	if len(content) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(content), base64.StdEncoding.EncodeToString(content))
+}
@@ -0,0 +1,185 @@
+package base
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testReport has a passed group and a group failing at its second step
+func testReport() *Report {
+	r := NewReport("suite")
+	r.Add(&GroupResult{
+		Group:    "login",
+		Start:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration: 1500 * time.Millisecond,
+		Steps: []StepResult{
+			{Index: 0, Name: "open", Method: "GoToPath", Args: []interface{}{"https://example.com"}, Status: StepPassed, Duration: time.Second},
+			{Index: 1, Name: "submit", Method: "ClickElement", Args: []interface{}{"#submit"}, Status: StepPassed, Duration: 500 * time.Millisecond},
+		},
+	})
+	r.Add(&GroupResult{
+		Group:    "search",
+		Duration: 2 * time.Second,
+		Steps: []StepResult{
+			{Index: 0, Name: "open", Method: "GoToPath", Status: StepPassed, Duration: time.Second},
+			{Index: 1, Name: "title", Method: "AssertTitle", Status: StepFailed, Err: errors.New(`expected "<Search>"`), Screenshot: []byte("\x89PNG\r\n\x1a\n")},
+			{Index: 2, Name: "click", Method: "ClickElement", Status: StepSkipped},
+		},
+		Err: errors.New("step title failed"),
+	})
+	r.Add(nil)
+
+	return r
+}
+
+func TestReportFailed(t *testing.T) {
+	r := NewReport("empty")
+	if r.Failed() {
+		t.Error("empty report failed")
+	}
+
+	if !testReport().Failed() {
+		t.Error("report with a failed group did not fail")
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xml")
+	if err := testReport().WriteJUnit(path); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), xml.Header) {
+		t.Error("the junit report has no xml header")
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(content, &suites); err != nil {
+		t.Fatal(err)
+	}
+
+	if suites.Name != "suite" || suites.Tests != 5 || suites.Failures != 1 || suites.Skipped != 1 || suites.Time != "3.500" {
+		t.Errorf("testsuites = %s %d tests %d failures %d skipped in %s", suites.Name, suites.Tests, suites.Failures, suites.Skipped, suites.Time)
+	}
+	if len(suites.Suites) != 2 {
+		t.Fatalf("%d suites, want 2", len(suites.Suites))
+	}
+
+	login := suites.Suites[0]
+	if login.Name != "login" || login.Timestamp != "2020-01-02T03:04:05Z" || login.Cases[1].Name != "01 submit" || login.Cases[1].ClassName != "suite.login" {
+		t.Errorf("login suite = %+v", login)
+	}
+
+	search := suites.Suites[1]
+	failure := search.Cases[1].Failure
+	if failure == nil || failure.Type != "AssertTitle" || failure.Message != `expected "<Search>"` {
+		t.Errorf("failure of the title step = %+v", failure)
+	}
+	if search.Cases[2].Skipped == nil {
+		t.Error("the skipped step is not skipped")
+	}
+	if search.SystemErr != "" {
+		t.Errorf("system-err = %q, want none since a step failed", search.SystemErr)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := testReport().WriteJSON(path); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jr jsonReport
+	if err := json.Unmarshal(content, &jr); err != nil {
+		t.Fatal(err)
+	}
+
+	if jr.Name != "suite" || !jr.Failed || len(jr.Groups) != 2 {
+		t.Fatalf("report = %s failed %v with %d groups", jr.Name, jr.Failed, len(jr.Groups))
+	}
+
+	login := jr.Groups[0]
+	if login.DurationMs != 1500 || login.Error != "" || login.Steps[0].Args[0] != "https://example.com" {
+		t.Errorf("login group = %+v", login)
+	}
+
+	title := jr.Groups[1].Steps[1]
+	if title.Status != StepFailed || title.Error != `expected "<Search>"` || !strings.HasPrefix(title.Screenshot, "data:image/png;base64,") {
+		t.Errorf("title step = %+v", title)
+	}
+	if jr.Groups[1].Steps[0].Screenshot != "" {
+		t.Error("a step without screenshot has one in the json")
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := testReport().WriteHTML(path); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(content)
+
+	for _, want := range []string{
+		"<title>suite</title>",
+		`<span class="failed">failed</span>`,
+		"<h2>login <small>1500 ms</small></h2>",
+		`<td class="skipped">skipped</td>`,
+		"expected &#34;&lt;Search&gt;&#34;",
+		`<img src="data:image/png;base64,`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("the html report has no %s", want)
+		}
+	}
+	if strings.Contains(html, "<Search>") {
+		t.Error("the html report does not escape the error")
+	}
+}
+
+func TestReportConcurrentAdd(t *testing.T) {
+	r := NewReport("parallel")
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				r.Add(&GroupResult{Group: fmt.Sprintf("group-%d-%d", i, j), Steps: []StepResult{{Status: StepPassed}}})
+			}
+			// the reports are written while the other groups are added
+			if err := r.WriteJSON(filepath.Join(dir, fmt.Sprintf("%d.json", i))); err != nil {
+				t.Error(err)
+			}
+			r.Failed()
+		}(i)
+	}
+	wg.Wait()
+
+	if len(r.groups()) != 160 {
+		t.Errorf("%d groups added, want 160", len(r.groups()))
+	}
+}
@@ -0,0 +1,293 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+var Devices = map[string]chromedp.Device{
+	"pc":                     PC,
+	"sony-xperia-xz-premium": SonyXPeriaXZPremium,
+}
+
+// Scenario describes the steps of one test, it can be written in yaml or json, for example:
+//
+//	name: example
+//	device: pc
+//	timeout: 10
+//	assertMode: soft
+//	steps:
+//	  - action: goto
+//	    url: https://example.com
+//	  - action: waitVisible
+//	    element:
+//	      tag: h1
+//	      contains:
+//	        - subject: text()
+//	          value: Example Domain
+//	  - action: assertTitle
+//	    match: contains
+//	    expected: Example
+//	  - action: screenshot
+//	    path: example.png
+type Scenario struct {
+	Name       string         `yaml:"name" json:"name"`
+	Device     string         `yaml:"device" json:"device"`
+	Timeout    int64          `yaml:"timeout" json:"timeout"`
+	AssertMode string         `yaml:"assertMode" json:"assertMode"`
+	Steps      []ScenarioStep `yaml:"steps" json:"steps"`
+}
+
+type ScenarioStep struct {
+	Name      string       `yaml:"name" json:"name"`
+	Action    string       `yaml:"action" json:"action"`
+	URL       string       `yaml:"url" json:"url"`
+	Selector  string       `yaml:"selector" json:"selector"`
+	Element   *ElementSpec `yaml:"element" json:"element"`
+	Value     string       `yaml:"value" json:"value"`
+	Key       string       `yaml:"key" json:"key"`
+	Attribute string       `yaml:"attribute" json:"attribute"`
+	Match     string       `yaml:"match" json:"match"`
+	Expected  string       `yaml:"expected" json:"expected"`
+	Count     int          `yaml:"count" json:"count"`
+	Path      string       `yaml:"path" json:"path"`
+	Seconds   int64        `yaml:"seconds" json:"seconds"`
+	// FullPage, Mask and Threshold are the options of assertScreenshot, the name of the step names the baseline
+	FullPage  bool     `yaml:"fullPage" json:"fullPage"`
+	Mask      []string `yaml:"mask" json:"mask"`
+	Threshold float64  `yaml:"threshold" json:"threshold"`
+}
+
+// ElementSpec describes an xpath selector built by the Element functions
+type ElementSpec struct {
+	Path       string       `yaml:"path" json:"path"`
+	Tag        string       `yaml:"tag" json:"tag"`
+	Position   int          `yaml:"position" json:"position"`
+	Attributes []FilterSpec `yaml:"attributes" json:"attributes"`
+	Contains   []FilterSpec `yaml:"contains" json:"contains"`
+	Equal      []FilterSpec `yaml:"equal" json:"equal"`
+	Child      *ElementSpec `yaml:"child" json:"child"`
+}
+
+// FilterSpec is one filter of an element, the subject is the attribute name for the attribute filters
+type FilterSpec struct {
+	Subject  string `yaml:"subject" json:"subject"`
+	Value    string `yaml:"value" json:"value"`
+	Position int    `yaml:"position" json:"position"`
+}
+
+func (es ElementSpec) Element() *Element {
+	e := HtmlTag(es.Tag, es.Position)
+	if es.Path != "" {
+		e.ByPath(es.Path)
+	}
+
+	for _, f := range es.Attributes {
+		e.ByAttribute(f.Subject, f.Value, f.Position)
+	}
+	for _, f := range es.Contains {
+		e.ByContains(f.Subject, f.Value, f.Position)
+	}
+	for _, f := range es.Equal {
+		e.ByEqual(f.Subject, f.Value, f.Position)
+	}
+
+	if es.Child != nil {
+		e.AddChild(es.Child.Element())
+	}
+
+	return e
+}
+
+// LoadScenario reads a scenario from a .json, .yaml or .yml file
+func LoadScenario(path string) (Scenario, error) {
+	var sc Scenario
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return sc, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &sc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &sc)
+	default:
+		return sc, fmt.Errorf("unknown scenario format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return sc, fmt.Errorf("could not parse scenario %s: %v", path, err)
+	}
+
+	if sc.Name == "" {
+		sc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if sc.Name == "" {
+		return sc, fmt.Errorf("scenario %s has no name", path)
+	}
+
+	return sc, nil
+}
+
+func DeviceByName(name string) (chromedp.Device, error) {
+	if name == "" {
+		return PC, nil
+	}
+
+	d, ok := Devices[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown device %q", name)
+	}
+
+	return d, nil
+}
+
+// RunScenario records the steps of the scenario into a group named by the scenario, then processes it.
+// The device of the scenario is emulated while it runs, then the device emulated before is restored.
+func (sm *SiteManager) RunScenario(sc Scenario, handleError bool) (*GroupResult, error) {
+	if sc.Name == "" {
+		// an empty group name would run the steps instead of recording them
+		err := errors.New("the scenario has no name")
+		sm.Error(err, handleError)
+		return nil, err
+	}
+
+	switch strings.ToLower(sc.AssertMode) {
+	case "", "hard":
+		sm.SetAssertMode(AssertHard)
+	case "soft":
+		sm.SetAssertMode(AssertSoft)
+	default:
+		err := fmt.Errorf("unknown assert mode %q", sc.AssertMode)
+		sm.Error(err, handleError)
+		return nil, err
+	}
+
+	if sc.Device != "" {
+		d, err := DeviceByName(sc.Device)
+		if err != nil {
+			sm.Error(err, handleError)
+			return nil, err
+		}
+
+		previous := sm.info
+		sm.emulate(d)
+		defer sm.emulate(previous)
+	}
+
+	activeGroup := sm.activeGroup
+	defer sm.Group(activeGroup)
+
+	sm.Group(sc.Name)
+	sm.groupActions[sc.Name] = nil
+
+	for i, step := range sc.Steps {
+		if err := sm.recordStep(step); err != nil {
+			err = fmt.Errorf("step %d of scenario %s: %v", i, sc.Name, err)
+			sm.Error(err, handleError)
+			return nil, err
+		}
+	}
+
+	return sm.GroupProcess(sc.Name, sc.Timeout, handleError)
+}
+
+func (sm *SiteManager) recordStep(step ScenarioStep) (err error) {
+	selector := step.Selector
+	if step.Element != nil {
+		selector = step.Element.Element().String()
+	}
+
+	matcher := Matcher(step.Match)
+	if step.Match == "" {
+		matcher = MatchEqual
+	}
+	for _, m := range []Matcher{MatchEqual, MatchContains, MatchRegex, MatchAtMost} {
+		if strings.EqualFold(step.Match, string(m)) {
+			matcher = m
+		}
+	}
+
+	sm.NameStep(step.Name)
+	defer func() {
+		// the name is not left for the next step if this one is not recorded
+		if err != nil {
+			sm.NameStep("")
+		}
+	}()
+
+	switch step.Action {
+	case "goto":
+		return sm.GoToPath(step.URL, 0, false)
+	case "fill":
+		return sm.FillField(selector, step.Value, 0, false)
+	case "click":
+		return sm.ClickElement(selector, 0, false)
+	case "doubleClick":
+		return sm.DoubleClickElement(selector, 0, false)
+	case "focus":
+		return sm.FocusElement(selector, 0, false)
+	case "clear":
+		return sm.ClearElement(selector, 0, false)
+	case "scrollTo":
+		return sm.ScrollTo(selector, 0, false)
+	case "waitVisible":
+		return sm.WaitVisible(selector, 0, false)
+	case "waitNotVisible":
+		return sm.WaitNotVisible(selector, 0, false)
+	case "waitEnabled":
+		return sm.WaitEnabled(selector, 0, false)
+	case "waitReady":
+		return sm.WaitReady(selector, 0, false)
+	case "waitSelected":
+		return sm.WaitSelected(selector, 0, false)
+	case "waitNotPresent":
+		return sm.WaitNotPresent(selector, 0, false)
+	case "wait":
+		return sm.Wait(step.Seconds, false)
+	case "press":
+		return sm.Press(step.Key, 0, false)
+	case "type":
+		return sm.Type(step.Value, 0, 0, false)
+	case "keyDown":
+		// a modifier is held for the next steps until keyUp, another key or chord is pressed
+		if _, ok := modifierKeys[keyName(step.Key)]; ok {
+			return sm.HoldModifier(step.Key, 0, false)
+		}
+		return sm.Press(step.Key, 0, false)
+	case "keyUp":
+		return sm.ReleaseModifier(step.Key, 0, false)
+	case "keyChar":
+		return sm.Type(step.Key, 0, 0, false)
+	case "screenshot":
+		return sm.CreateScreenShot(step.Path, 0, false)
+	case "assertText":
+		return sm.AssertText(selector, matcher, step.Expected, 0, false)
+	case "assertAttribute":
+		return sm.AssertAttribute(selector, step.Attribute, matcher, step.Expected, 0, false)
+	case "assertCount":
+		return sm.AssertCount(selector, step.Count, 0, false)
+	case "assertURL":
+		return sm.AssertURL(matcher, step.Expected, 0, false)
+	case "assertTitle":
+		return sm.AssertTitle(matcher, step.Expected, 0, false)
+	case "assertScreenshot":
+		if step.Name == "" {
+			return errors.New("assertScreenshot needs a name for its baseline")
+		}
+		opts := VisualOptions{Threshold: step.Threshold}
+		opts.Selector = selector
+		opts.FullPage = step.FullPage
+		opts.Mask = step.Mask
+		return sm.AssertScreenshot(step.Name, opts, 0, false)
+	}
+
+	return fmt.Errorf("unknown action %q", step.Action)
+}
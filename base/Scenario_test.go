@@ -0,0 +1,163 @@
+package base
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadScenario(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		name    string
+		steps   int
+		wantErr bool
+	}{
+		{"named.yaml", "name: login\nsteps:\n  - action: goto\n    url: https://example.com\n", "login", 1, false},
+		{"unnamed.yml", "steps:\n  - action: goto\n  - action: click\n", "unnamed", 2, false},
+		{"named.json", `{"name": "search", "steps": [{"action": "goto"}]}`, "search", 1, false},
+		{"unnamed.json", `{"steps": []}`, "unnamed", 0, false},
+		{".yaml", "steps:\n  - action: goto\n", "", 0, true},
+		{"broken.yaml", "steps: [", "", 0, true},
+		{"scenario.txt", "name: text", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sc, err := LoadScenario(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadScenario() = %+v, want error", sc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadScenario() error = %v", err)
+			}
+			if sc.Name != tt.name {
+				t.Errorf("Name = %q, want %q", sc.Name, tt.name)
+			}
+			if len(sc.Steps) != tt.steps {
+				t.Errorf("len(Steps) = %d, want %d", len(sc.Steps), tt.steps)
+			}
+		})
+	}
+}
+
+func TestRunScenarioWithoutName(t *testing.T) {
+	sm := &SiteManager{groupActions: make(map[string][]groupAction)}
+
+	_, err := sm.RunScenario(Scenario{Steps: []ScenarioStep{{Action: "goto", URL: "https://example.com"}}}, false)
+	if err == nil {
+		t.Fatal("RunScenario() without name succeeded, want error")
+	}
+	if len(sm.groupActions) != 0 {
+		t.Errorf("RunScenario() recorded %v, want nothing", sm.groupActions)
+	}
+}
+
+func TestRecordStep(t *testing.T) {
+	tests := []struct {
+		step    ScenarioStep
+		method  string
+		name    string
+		wantErr bool
+	}{
+		{ScenarioStep{Action: "goto", URL: "https://example.com"}, "GoToPath", "GoToPath", false},
+		{ScenarioStep{Action: "click", Selector: "#submit", Name: "submit"}, "ClickElement", "submit", false},
+		{ScenarioStep{Action: "fill", Selector: "#q", Value: "watat"}, "FillField", "FillField", false},
+		{ScenarioStep{Action: "assertTitle", Match: "contains", Expected: "Example"}, "AssertTitle", "AssertTitle", false},
+		{ScenarioStep{Action: "assertScreenshot", Name: "home"}, "AssertScreenshot", "home", false},
+		{ScenarioStep{Action: "assertScreenshot"}, "", "", true},
+		{ScenarioStep{Action: "fly"}, "", "", true},
+		{ScenarioStep{Action: "press", Key: "Control+A"}, "Press", "Press", false},
+		{ScenarioStep{Action: "type", Value: "hello"}, "Type", "Type", false},
+		// the keys go through Press and Type, a name of several runes is a key name or a chord
+		{ScenarioStep{Action: "keyDown", Key: "Enter"}, "Press", "Press", false},
+		{ScenarioStep{Action: "keyDown", Key: "Shift"}, "HoldModifier", "HoldModifier", false},
+		{ScenarioStep{Action: "keyUp", Key: "Ctrl", Name: "release"}, "ReleaseModifier", "release", false},
+		{ScenarioStep{Action: "keyChar", Key: "é"}, "Type", "Type", false},
+		{ScenarioStep{Action: "keyDown", Key: "Nope", Name: "bad key"}, "", "", true},
+		{ScenarioStep{Action: "keyUp", Key: "Enter", Name: "not a modifier"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.step.Action+"/"+tt.step.Name, func(t *testing.T) {
+			sm := &SiteManager{groupActions: make(map[string][]groupAction)}
+			sm.Group("scenario")
+
+			err := sm.recordStep(tt.step)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("recordStep() succeeded, want error")
+				}
+				if sm.nextStepName != "" {
+					t.Errorf("the step name %q is left for the next step", sm.nextStepName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("recordStep() error = %v", err)
+			}
+
+			actions := sm.groupActions["scenario"]
+			if len(actions) != 1 {
+				t.Fatalf("recorded %d actions, want 1", len(actions))
+			}
+			if actions[0].method != tt.method || actions[0].name != tt.name {
+				t.Errorf("recorded %s named %q, want %s named %q", actions[0].method, actions[0].name, tt.method, tt.name)
+			}
+		})
+	}
+}
+
+func TestRecordStepMatcher(t *testing.T) {
+	tests := []struct {
+		match string
+		want  Matcher
+	}{
+		{"", MatchEqual},
+		{"Contains", MatchContains},
+		{"REGEX", MatchRegex},
+		{"atMost", MatchAtMost},
+		{"atmost", MatchAtMost},
+		{"between", Matcher("between")},
+	}
+
+	for _, tt := range tests {
+		sm := &SiteManager{groupActions: make(map[string][]groupAction)}
+		sm.Group("scenario")
+		if err := sm.recordStep(ScenarioStep{Action: "assertTitle", Match: tt.match, Expected: "1"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := sm.groupActions["scenario"][0].args[0]; got != tt.want {
+			t.Errorf("match %q recorded matcher %q, want %q", tt.match, got, tt.want)
+		}
+	}
+}
+
+func TestRunScenarioDevice(t *testing.T) {
+	sm := newTestManager()
+
+	result, err := sm.RunScenario(Scenario{Name: "mobile", Device: "sony-xperia-xz-premium", Steps: []ScenarioStep{{Action: "goto", URL: "https://example.com"}}}, false)
+	if result == nil || err == nil {
+		t.Fatalf("RunScenario() = %v, %v, want the failed result of the manager without browser", result, err)
+	}
+	if sm.info.Device().Name != PC.Name {
+		t.Errorf("the emulated device after the scenario is %s, want %s", sm.info.Device().Name, PC.Name)
+	}
+
+	if _, err := sm.RunScenario(Scenario{Name: "toaster", Device: "toaster"}, false); err == nil {
+		t.Error("RunScenario() of an unknown device succeeded, want error")
+	}
+	if len(sm.groupActions["toaster"]) != 0 {
+		t.Error("the scenario of an unknown device recorded steps")
+	}
+}
@@ -0,0 +1,271 @@
+package base
+
+import (
+	"context"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+type ScreenshotFormat string
+
+const (
+	ScreenshotPNG  ScreenshotFormat = "png"
+	ScreenshotJPEG ScreenshotFormat = "jpeg"
+	ScreenshotWebP ScreenshotFormat = "webp"
+)
+
+type ScreenshotOptions struct {
+	// FullPage captures the whole page, not only the part in the viewport
+	FullPage bool
+	// Selector clips the screenshot to the first element of the selector
+	Selector string
+	// Format is png by default, SaveScreenshot takes it from the extension of the file if it is empty
+	Format ScreenshotFormat
+	// Quality of the jpeg or webp from 0 to 100, the default of chrome is used if zero
+	Quality int64
+	// Mask are the selectors of the changing elements (dates, ads, ...), every element of them is covered by a box of the MaskColor
+	Mask      []string
+	MaskColor string
+}
+
+const defaultMaskColor = "#ff00ff"
+
+// maskAttribute marks the boxes covering the masked elements, to remove them after the capture
+const maskAttribute = "data-watat-mask"
+
+const maskJS = `function(color) {
+	var r = this.getBoundingClientRect();
+	var box = document.createElement('div');
+	box.setAttribute('` + maskAttribute + `', '');
+	box.style.cssText = 'position: absolute; z-index: 2147483647; pointer-events: none; margin: 0; border: 0; padding: 0;';
+	box.style.left = (r.left + window.scrollX) + 'px';
+	box.style.top = (r.top + window.scrollY) + 'px';
+	box.style.width = r.width + 'px';
+	box.style.height = r.height + 'px';
+	box.style.background = color;
+	document.documentElement.appendChild(box);
+}`
+
+const unmaskJS = `document.querySelectorAll('[` + maskAttribute + `]').forEach(function(box) { box.remove(); })`
+
+// ScreenshotInto captures a screenshot by the options into the pointer, the report embeds it like the one of CaptureScreenshotInto
+func (sm *SiteManager) ScreenshotInto(into *[]byte, opts ScreenshotOptions, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		shot, err := sm.captureScreenshot(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		*into = shot
+		sm.attachScreenshot(shot)
+
+		return nil
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ScreenshotInto", []interface{}{opts.Selector, opts.FullPage}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// SaveScreenshot captures a screenshot by the options into the file, a .jpg or .jpeg file is saved as jpeg and a .webp file as webp if the format is not set
+func (sm *SiteManager) SaveScreenshot(filename string, opts ScreenshotOptions, timeoutSec int64, handleError bool) error {
+	opts.Format = fileFormat(filename, opts.Format)
+
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		shot, err := sm.captureScreenshot(ctx, opts)
+		if err != nil {
+			return err
+		}
+		sm.attachScreenshot(shot)
+
+		return ioutil.WriteFile(filename, shot, 0644)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SaveScreenshot", []interface{}{filename, opts.Selector, opts.FullPage}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// fileFormat returns the format if it is set, or the format of the extension of the file
+func fileFormat(filename string, format ScreenshotFormat) ScreenshotFormat {
+	if format != "" {
+		return format
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg":
+		return ScreenshotJPEG
+	case ".webp":
+		return ScreenshotWebP
+	}
+
+	return ScreenshotPNG
+}
+
+func (sm *SiteManager) captureScreenshot(ctx context.Context, opts ScreenshotOptions) ([]byte, error) {
+	var clip *page.Viewport
+
+	// the viewport is resized to the page to capture beyond it, then the device of the SiteManager is emulated again
+	resized := false
+	defer func() {
+		if resized {
+			if err := chromedp.Tasks(sm.fixActions).Do(ctx); err != nil {
+				sm.logf("could not restore the viewport after the screenshot: %v", err)
+			}
+		}
+	}()
+
+	if opts.FullPage {
+		if err := resizeToContent(ctx, sm.info); err != nil {
+			return nil, err
+		}
+		resized = true
+
+		_, _, content, err := page.GetLayoutMetrics().Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clip = &page.Viewport{Width: math.Ceil(content.Width), Height: math.Ceil(content.Height), Scale: 1}
+	}
+
+	if opts.Selector != "" {
+		el, err := resolveNode(ctx, opts.Selector)
+		if err != nil {
+			return nil, err
+		}
+
+		box, inViewport, err := elementBox(ctx, el)
+		if err != nil {
+			return nil, err
+		}
+		if !inViewport && !resized {
+			if err := resizeToContent(ctx, sm.info); err != nil {
+				return nil, err
+			}
+			resized = true
+
+			if box, _, err = elementBox(ctx, el); err != nil {
+				return nil, err
+			}
+		}
+		clip = box
+	}
+
+	if len(opts.Mask) > 0 {
+		color := opts.MaskColor
+		if color == "" {
+			color = defaultMaskColor
+		}
+		if err := maskElements(ctx, opts.Mask, color); err != nil {
+			return nil, err
+		}
+		defer func() {
+			_, exp, err := runtime.Evaluate(unmaskJS).Do(ctx)
+			if err == nil && exp != nil {
+				err = exp
+			}
+			if err != nil {
+				sm.logf("could not remove the masks after the screenshot: %v", err)
+			}
+		}()
+	}
+
+	capture := page.CaptureScreenshot()
+	switch opts.Format {
+	case ScreenshotJPEG, ScreenshotWebP:
+		capture = capture.WithFormat(page.CaptureScreenshotFormat(opts.Format))
+		if opts.Quality > 0 {
+			capture = capture.WithQuality(opts.Quality)
+		}
+	default:
+		capture = capture.WithFormat(page.CaptureScreenshotFormatPng)
+	}
+	if clip != nil {
+		capture = capture.WithClip(clip)
+	}
+
+	return capture.Do(ctx)
+}
+
+// resizeToContent makes the viewport as tall as the content of the page, so the whole page is painted, the device stays emulated
+func resizeToContent(ctx context.Context, d chromedp.Device) error {
+	viewport, _, content, err := page.GetLayoutMetrics().Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	width := int64(math.Max(float64(viewport.ClientWidth), math.Ceil(content.Width)))
+	height := int64(math.Max(float64(viewport.ClientHeight), math.Ceil(content.Height)))
+
+	return chromedp.EmulateViewport(width, height, viewportOptions(d)...).Do(ctx)
+}
+
+// elementBox returns the box of the element in the coordinates of the page, and tells if it fits into the viewport
+func elementBox(ctx context.Context, el *runtime.RemoteObject) (*page.Viewport, bool, error) {
+	quad, err := elementQuad(ctx, el)
+	if err != nil {
+		return nil, false, err
+	}
+
+	left, top := math.Inf(1), math.Inf(1)
+	right, bottom := math.Inf(-1), math.Inf(-1)
+	for i := 0; i < len(quad); i += 2 {
+		left, right = math.Min(left, quad[i]), math.Max(right, quad[i])
+		top, bottom = math.Min(top, quad[i+1]), math.Max(bottom, quad[i+1])
+	}
+
+	viewport, _, _, err := page.GetLayoutMetrics().Do(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	box := &page.Viewport{
+		X:      left + float64(viewport.PageX),
+		Y:      top + float64(viewport.PageY),
+		Width:  right - left,
+		Height: bottom - top,
+		Scale:  1,
+	}
+	inViewport := left >= 0 && top >= 0 && right <= float64(viewport.ClientWidth) && bottom <= float64(viewport.ClientHeight)
+
+	return box, inViewport, nil
+}
+
+// maskElements covers every element of the selectors, the selectors without elements are skipped
+func maskElements(ctx context.Context, selectors []string, color string) error {
+	for _, selector := range selectors {
+		var nodes []*cdp.Node
+		if err := chromedp.Nodes(selector, &nodes, chromedp.AtLeast(0)).Do(ctx); err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			el, err := dom.ResolveNode().WithNodeID(node.NodeID).Do(ctx)
+			if err != nil {
+				return err
+			}
+			if err := callOn(ctx, el, maskJS, nil, color); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
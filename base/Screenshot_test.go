@@ -0,0 +1,111 @@
+package base
+
+import (
+	"fmt"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		format   ScreenshotFormat
+		want     ScreenshotFormat
+	}{
+		{"home.png", "", ScreenshotPNG},
+		{"home.jpg", "", ScreenshotJPEG},
+		{"home.JPEG", "", ScreenshotJPEG},
+		{"home.webp", "", ScreenshotWebP},
+		{"home", "", ScreenshotPNG},
+		{"shots.d/home.gif", "", ScreenshotPNG},
+		// the format set wins over the extension
+		{"home.png", ScreenshotJPEG, ScreenshotJPEG},
+	}
+
+	for _, tt := range tests {
+		if got := fileFormat(tt.filename, tt.format); got != tt.want {
+			t.Errorf("fileFormat(%q, %q) = %q, want %q", tt.filename, tt.format, got, tt.want)
+		}
+	}
+}
+
+// layoutMetrics is the answer of Page.getLayoutMetrics for the viewport scrolled to pageY and the content size
+func layoutMetrics(pageY, width, height, contentHeight int) string {
+	return fmt.Sprintf(`{"layoutViewport": {"pageX": 0, "pageY": %d, "clientWidth": %d, "clientHeight": %d}, "visualViewport": {}, "contentSize": {"x": 0, "y": 0, "width": %d, "height": %d}}`,
+		pageY, width, height, width, contentHeight)
+}
+
+func TestElementBox(t *testing.T) {
+	tests := []struct {
+		name           string
+		quad           string
+		wantBox        page.Viewport
+		wantInViewport bool
+	}{
+		{"in the viewport", "[10, 20, 110, 20, 110, 70, 10, 70]", page.Viewport{X: 10, Y: 320, Width: 100, Height: 50, Scale: 1}, true},
+		{"taller than the viewport", "[0, 100, 800, 100, 800, 900, 0, 900]", page.Viewport{X: 0, Y: 400, Width: 800, Height: 800, Scale: 1}, false},
+		{"out on the left", "[-5, 0, 45, 0, 45, 10, -5, 10]", page.Viewport{X: -5, Y: 300, Width: 50, Height: 10, Scale: 1}, false},
+		// a rotated element is boxed by its corners
+		{"rotated", "[50, 0, 100, 50, 50, 100, 0, 50]", page.Viewport{X: 0, Y: 300, Width: 100, Height: 100, Scale: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := newFakeTab()
+			ft.answer("Runtime.callFunctionOn", `{"result": {"type": "undefined"}}`)
+			ft.answer("DOM.getContentQuads", `{"quads": [`+tt.quad+`]}`)
+			ft.answer("Page.getLayoutMetrics", layoutMetrics(300, 800, 600, 3000))
+
+			box, inViewport, err := elementBox(ft.context(), &runtime.RemoteObject{ObjectID: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *box != tt.wantBox || inViewport != tt.wantInViewport {
+				t.Errorf("elementBox() = %+v, %v, want %+v, %v", *box, inViewport, tt.wantBox, tt.wantInViewport)
+			}
+		})
+	}
+}
+
+func TestCaptureScreenshotRestoresViewport(t *testing.T) {
+	tests := []struct {
+		name      string
+		capture   string
+		wantErr   bool
+		wantShots int
+	}{
+		{"captured", `{"data": "c2hvdA=="}`, false, 1},
+		// the device is emulated again when the capture fails too
+		{"failed", "!capture failed", true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestManager()
+			ft := newFakeTab()
+			ft.answer("Page.getLayoutMetrics", layoutMetrics(0, 1920, 1080, 5000))
+			ft.answer("Page.captureScreenshot", tt.capture)
+
+			shot, err := sm.captureScreenshot(ft.context(), ScreenshotOptions{FullPage: true, Format: ScreenshotWebP, Quality: 80})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("captureScreenshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(shot) != "shot" {
+				t.Errorf("captureScreenshot() = %q", shot)
+			}
+
+			captures := ft.sent("Page.captureScreenshot")
+			if len(captures) != tt.wantShots || !strings.Contains(captures[0], `"format":"webp","quality":80`) || !strings.Contains(captures[0], `"height":5000`) {
+				t.Errorf("captured by %q", captures)
+			}
+
+			// resized to the page, then back to the device
+			metrics := ft.sent("Emulation.setDeviceMetricsOverride")
+			if len(metrics) != 2 || !strings.Contains(metrics[0], `"width":1920,"height":5000`) || !strings.Contains(metrics[1], `"width":1920,"height":1080`) {
+				t.Errorf("device metrics set %q, want the page size then the device size", metrics)
+			}
+		})
+	}
+}
@@ -39,45 +39,172 @@ var (
 
 type SiteManager struct {
 	ctx          context.Context
+	tabCtx       context.Context
 	cancel       []context.CancelFunc
+	config       config
 	info         chromedp.Device
 	errorHandler func(err error)
 	timeoutSec   int64
+	// deadline ends the session started by setup or by Pool.Acquire, the context of every tab switched to in it ends at it too
+	deadline  time.Time
+	ctxCancel context.CancelFunc
 
 	activeGroup  string
-	groupActions map[string][]chromedp.Action
+	groupActions map[string][]groupAction
+	nextStepName string
+
+	failureScreenshot bool
+	runningStep       *StepResult
+	report            *Report
+
+	handlers    map[string]eventHandler
+	tabs        *tabSet
+	interceptor *interceptor
+	har         *harRecording
+
+	console            *consoleBuffer
+	failOnConsoleError bool
+
+	dialogs     *dialogQueue
+	fileChooser *fileChooserWaiter
+	downloads   *downloadQueue
+	keyboard    *keyboardState
 
 	fixActions []chromedp.Action
+
+	assertMode     AssertMode
+	assertFailures AssertionErrors
+
+	baselineDir     string
+	updateBaselines bool
+
+	// isolated are the incognito contexts created by NewIsolatedContext
+	isolated []*SiteManager
+}
+
+// New starts a browser configured by the options and returns its SiteManager, the browser is closed by Cancel
+func New(opts ...ManagerOption) (*SiteManager, error) {
+	sm := &SiteManager{}
+	if err := sm.start(newConfig(opts...)); err != nil {
+		return nil, err
+	}
+
+	return sm, nil
 }
 
+// errNotStarted is returned by the actions of a SiteManager whose browser could not be started
+var errNotStarted = errors.New("the browser of the SiteManager is not started")
+
+// Init starts the browser like New with WithDevice, WithTimeout, WithHeadless and WithIgnoreCertErrors.
+// If the browser can not be started, the error is logged and the actions of the SiteManager return an error, use New to get it.
 func (sm *SiteManager) Init(d chromedp.Device, defTimeoutSec int64, headless bool, ignoreCertErrors bool) {
-	sm.info = d
-	sm.groupActions = make(map[string][]chromedp.Action)
+	if err := sm.init(d, defTimeoutSec, headless, ignoreCertErrors); err != nil {
+		sm.logf("%v", err)
+	}
+}
 
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.Flag("headless", headless),
-		chromedp.Flag("ignore-certificate-errors", ignoreCertErrors),
-	)
+func (sm *SiteManager) init(d chromedp.Device, defTimeoutSec int64, headless bool, ignoreCertErrors bool) error {
+	return sm.start(newConfig(
+		WithDevice(d),
+		WithTimeout(defTimeoutSec),
+		WithHeadless(headless),
+		WithIgnoreCertErrors(ignoreCertErrors),
+	))
+}
 
-	neaCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+func (sm *SiteManager) start(c config) error {
+	var neaCtx context.Context
+	var cancel context.CancelFunc
+	if c.remoteURL != "" {
+		neaCtx, cancel = chromedp.NewRemoteAllocator(context.Background(), c.remoteURL)
+	} else {
+		neaCtx, cancel = chromedp.NewExecAllocator(context.Background(), c.allocatorOptions()...)
+	}
 	sm.cancel = append(sm.cancel, cancel)
 
-	// also set up a custom logger
-	ctx, tCancel := chromedp.NewContext(neaCtx, chromedp.WithLogf(log.Printf))
+	ctx, tCancel := chromedp.NewContext(neaCtx, chromedp.WithLogf(c.logf))
 	sm.cancel = append(sm.cancel, tCancel)
 
-	if defTimeoutSec > 0 {
-		// create a timeout
-		taskCtx, ttCancel := context.WithTimeout(ctx, time.Duration(defTimeoutSec)*time.Second)
-		sm.cancel = append(sm.cancel, ttCancel)
-		sm.ctx = taskCtx
-	} else {
-		sm.ctx = ctx
+	// start the browser now, to report if it can not be started
+	if err := chromedp.Run(ctx); err != nil {
+		sm.Cancel()
+		return fmt.Errorf("could not start the browser: %v", err)
+	}
+
+	return sm.setup(c, ctx)
+}
+
+// setup prepares the SiteManager on the started tab of the context
+func (sm *SiteManager) setup(c config, ctx context.Context) error {
+	sm.config = c
+	sm.info = c.device
+	sm.groupActions = make(map[string][]groupAction)
+	sm.interceptor = &interceptor{}
+	sm.har = &harRecording{}
+	sm.tabCtx = ctx
+	sm.timeoutSec = c.timeoutSec
+	sm.startTimeout()
+
+	if err := sm.watchTabs(ctx); err != nil {
+		return err
+	}
+
+	sm.console = newConsoleBuffer()
+	sm.listen("console", sm.onConsoleEvent)
+
+	sm.dialogs = newDialogQueue()
+	sm.listen("dialog", sm.onDialogEvent)
+
+	sm.fileChooser = &fileChooserWaiter{}
+	sm.downloads = newDownloadQueue()
+	sm.keyboard = &keyboardState{}
+
+	sm.emulate(sm.info)
+
+	if c.stateFile != "" {
+		return sm.RestoreState(c.stateFile, 0, false)
+	}
+
+	return nil
+}
+
+// startTimeout limits the session by the default timeout, from now on: when the SiteManager starts and when the pool hands it out
+func (sm *SiteManager) startTimeout() {
+	sm.deadline = time.Time{}
+	if sm.timeoutSec > 0 {
+		sm.deadline = time.Now().Add(time.Duration(sm.timeoutSec) * time.Second)
+	}
+
+	sm.useDeadline()
+}
+
+// useDeadline derives the context of the actions from the context of the active tab, ending at the deadline of the session
+func (sm *SiteManager) useDeadline() {
+	if sm.ctxCancel != nil {
+		sm.ctxCancel()
+		sm.ctxCancel = nil
+	}
+
+	if sm.tabCtx == nil {
+		// the browser is not started, the actions return errNotStarted
+		return
+	}
+	if sm.deadline.IsZero() {
+		sm.ctx = sm.tabCtx
+		return
+	}
+
+	sm.ctx, sm.ctxCancel = context.WithDeadline(sm.tabCtx, sm.deadline)
+}
+
+// logf logs through the logger set by WithLogger
+func (sm SiteManager) logf(format string, args ...interface{}) {
+	if sm.config.logf == nil {
+		log.Printf(format, args...)
+		return
 	}
 
-	sm.fixActions = append(sm.fixActions, chromedp.EmulateViewport(sm.info.Device().Width, sm.info.Device().Height))
-	sm.timeoutSec = defTimeoutSec
+	sm.config.logf(format, args...)
 }
 
 func (sm *SiteManager) Group(group string) {
@@ -85,9 +212,9 @@ func (sm *SiteManager) Group(group string) {
 }
 
 // if group is empty, it will check for the actual group and run actions from it
-func (sm *SiteManager) GroupProcess(group string, timeoutSecs int64, handleError bool) error {
+func (sm *SiteManager) GroupProcess(group string, timeoutSecs int64, handleError bool) (*GroupResult, error) {
 	if group == "" && sm.activeGroup == "" {
-		return errors.New("could not run actions connected to empty group")
+		return nil, errors.New("could not run actions connected to empty group")
 	}
 
 	if group == "" {
@@ -97,20 +224,49 @@ func (sm *SiteManager) GroupProcess(group string, timeoutSecs int64, handleError
 	actions, gok := sm.groupActions[group]
 
 	if !gok || len(actions) == 0 {
-		return errors.New(`your group does not exists, or there is no related action`)
+		return nil, errors.New(`your group does not exists, or there is no related action`)
 	}
 
-	err := sm.DoTimeoutContext(timeoutSecs, false, sm.groupActions[group]...)
+	result := sm.runGroup(group, actions, timeoutSecs)
 
-	sm.Error(err, handleError)
+	if sm.report != nil {
+		sm.report.Add(result)
+	}
 
-	return err
+	sm.Error(result.Err, handleError)
+
+	return result, result.Err
+}
+
+// NameStep sets the name of the next action recorded into the active group
+func (sm *SiteManager) NameStep(name string) {
+	sm.nextStepName = name
+}
+
+// SetFailureScreenshot enables capturing a screenshot into the result of the failing step
+func (sm *SiteManager) SetFailureScreenshot(enabled bool) {
+	sm.failureScreenshot = enabled
+}
+
+func (sm *SiteManager) addGroupAction(method string, args []interface{}, action chromedp.Action) {
+	name := sm.nextStepName
+	if name == "" {
+		name = method
+	}
+	sm.nextStepName = ""
+
+	sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], groupAction{
+		name:   name,
+		method: method,
+		args:   args,
+		action: action,
+	})
 }
 
 func (sm *SiteManager) GoToPath(url string, timoutSec int64, handleError bool) error {
 	action := chromedp.Navigate(url)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("GoToPath", []interface{}{url}, action)
 		return nil
 	}
 	err := sm.DoTimeoutContext(timoutSec, false, action)
@@ -121,9 +277,16 @@ func (sm *SiteManager) GoToPath(url string, timoutSec int64, handleError bool) e
 }
 
 func (sm *SiteManager) CaptureScreenshotInto(contentInto *[]byte, timeoutSec int64, handleError bool) error {
-	action := chromedp.CaptureScreenshot(contentInto)
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := chromedp.CaptureScreenshot(contentInto).Do(ctx); err != nil {
+			return err
+		}
+		// the report embeds the screenshots of the steps
+		sm.attachScreenshot(*contentInto)
+		return nil
+	})
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("CaptureScreenshotInto", nil, action)
 		return nil
 	}
 	err := sm.DoTimeoutContext(timeoutSec, false, action)
@@ -146,7 +309,7 @@ func (sm *SiteManager) CreateScreenShot(filename string, timeoutSec int64, handl
 		return err
 	})
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("CreateScreenShot", []interface{}{filename}, action)
 		return nil
 	}
 	err := sm.DoTimeoutContext(timeoutSec, false, action)
@@ -162,9 +325,20 @@ func (sm *SiteManager) CreateScreenShot(filename string, timeoutSec int64, handl
 }
 
 func (sm *SiteManager) Cancel() {
+	for _, isolated := range sm.isolated {
+		isolated.Cancel()
+	}
+	sm.isolated = nil
+
+	if sm.ctxCancel != nil {
+		sm.ctxCancel()
+		sm.ctxCancel = nil
+	}
 	for i := len(sm.cancel) - 1; i >= 0; i-- {
 		sm.cancel[i]()
 	}
+	// a second Cancel has nothing to do
+	sm.cancel = nil
 }
 
 func (sm SiteManager) ByID(path, tag, id string) string {
@@ -193,7 +367,7 @@ func (sm *SiteManager) FillFields(fields []map[string]interface{}, timeoutSec in
 	}
 
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], actions...)
+		sm.addGroupAction("FillFields", []interface{}{fields}, chromedp.Tasks(actions))
 		return nil
 	}
 
@@ -210,7 +384,7 @@ func (sm *SiteManager) FillField(identifier string, value string, timeoutSec int
 	actions = append(actions, chromedp.SendKeys(identifier, value, options...))
 
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], actions...)
+		sm.addGroupAction("FillField", []interface{}{identifier, value}, chromedp.Tasks(actions))
 		return nil
 	}
 
@@ -223,7 +397,7 @@ func (sm *SiteManager) FillField(identifier string, value string, timeoutSec int
 func (sm *SiteManager) ScrollTo(identifier string, timeoutSec int64, handleError bool) error {
 	action := chromedp.ScrollIntoView(identifier)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("ScrollTo", []interface{}{identifier}, action)
 		return nil
 	}
 
@@ -236,7 +410,7 @@ func (sm *SiteManager) ScrollTo(identifier string, timeoutSec int64, handleError
 func (sm *SiteManager) WaitEnabled(selector string, timeoutSec int64, handleError bool) error {
 	action := chromedp.WaitEnabled(selector)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("WaitEnabled", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -249,7 +423,7 @@ func (sm *SiteManager) WaitEnabled(selector string, timeoutSec int64, handleErro
 func (sm *SiteManager) WaitNotPresent(selector string, timeoutSec int64, handleError bool) error {
 	action := chromedp.WaitNotPresent(selector)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("WaitNotPresent", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -262,7 +436,7 @@ func (sm *SiteManager) WaitNotPresent(selector string, timeoutSec int64, handleE
 func (sm *SiteManager) WaitNotVisible(selector string, timeoutSec int64, handleError bool) error {
 	action := chromedp.WaitNotVisible(selector)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("WaitNotVisible", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -275,7 +449,7 @@ func (sm *SiteManager) WaitNotVisible(selector string, timeoutSec int64, handleE
 func (sm *SiteManager) WaitVisible(selector string, timeoutSec int64, handleError bool) error {
 	action := chromedp.WaitVisible(selector)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("WaitVisible", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -288,7 +462,7 @@ func (sm *SiteManager) WaitVisible(selector string, timeoutSec int64, handleErro
 func (sm *SiteManager) WaitSelected(selector string, timeoutSec int64, handleError bool) error {
 	action := chromedp.WaitSelected(selector)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("WaitSelected", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -301,7 +475,7 @@ func (sm *SiteManager) WaitSelected(selector string, timeoutSec int64, handleErr
 func (sm *SiteManager) WaitReady(selector string, timeoutSec int64, handleError bool) error {
 	action := chromedp.WaitReady(selector)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("WaitReady", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -321,7 +495,7 @@ func (sm *SiteManager) WaitReady(selector string, timeoutSec int64, handleError
 func (sm *SiteManager) ClickElement(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
 	action := chromedp.Click(selector, options...)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("ClickElement", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -334,7 +508,7 @@ func (sm *SiteManager) ClickElement(selector string, timeoutSec int64, handleErr
 func (sm *SiteManager) Wait(secs int64, handleError bool) error {
 	action := chromedp.Sleep(time.Second * time.Duration(secs))
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("Wait", []interface{}{secs}, action)
 		return nil
 	}
 
@@ -346,7 +520,7 @@ func (sm *SiteManager) Wait(secs int64, handleError bool) error {
 
 func (sm *SiteManager) CustomAction(action chromedp.ActionFunc, timeoutSec int64, handleError bool) error {
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("CustomAction", nil, action)
 		return nil
 	}
 
@@ -359,7 +533,7 @@ func (sm *SiteManager) CustomAction(action chromedp.ActionFunc, timeoutSec int64
 func (sm *SiteManager) FocusElement(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
 	action := chromedp.Focus(selector, options...)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("FocusElement", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -372,7 +546,7 @@ func (sm *SiteManager) FocusElement(selector string, timeoutSec int64, handleErr
 func (sm *SiteManager) ClearElement(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
 	action := chromedp.Clear(selector, options...)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("ClearElement", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -385,7 +559,7 @@ func (sm *SiteManager) ClearElement(selector string, timeoutSec int64, handleErr
 func (sm *SiteManager) DoubleClickElement(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
 	action := chromedp.DoubleClick(selector, options...)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("DoubleClickElement", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -398,7 +572,7 @@ func (sm *SiteManager) DoubleClickElement(selector string, timeoutSec int64, han
 func (sm *SiteManager) InnerHTMLInto(selector string, timeoutSec int64, html *string, handleError bool) error {
 	action := chromedp.InnerHTML(selector, html)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("InnerHTMLInto", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -411,7 +585,7 @@ func (sm *SiteManager) InnerHTMLInto(selector string, timeoutSec int64, html *st
 func (sm *SiteManager) OuterHTMLInto(selector string, timeoutSec int64, html *string, handleError bool) error {
 	action := chromedp.OuterHTML(selector, html)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("OuterHTMLInto", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -424,7 +598,7 @@ func (sm *SiteManager) OuterHTMLInto(selector string, timeoutSec int64, html *st
 func (sm *SiteManager) TextInto(selector string, timeoutSec int64, text *string, handleError bool) error {
 	action := chromedp.Text(selector, text)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("TextInto", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -437,7 +611,7 @@ func (sm *SiteManager) TextInto(selector string, timeoutSec int64, text *string,
 func (sm *SiteManager) GetElementAttributeValue(selector string, attribute string, into *string, ok *bool, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
 	action := chromedp.AttributeValue(selector, attribute, into, ok, options...)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("GetElementAttributeValue", []interface{}{selector, attribute}, action)
 		return nil
 	}
 
@@ -450,7 +624,7 @@ func (sm *SiteManager) GetElementAttributeValue(selector string, attribute strin
 func (sm *SiteManager) GetElementAttributes(selector string, into *map[string]string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
 	action := chromedp.Attributes(selector, into, options...)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("GetElementAttributes", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -463,7 +637,7 @@ func (sm *SiteManager) GetElementAttributes(selector string, into *map[string]st
 func (sm *SiteManager) GetElementsAttributes(selector string, into *[]map[string]string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
 	action := chromedp.AttributesAll(selector, into, options...)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("GetElementsAttributes", []interface{}{selector}, action)
 		return nil
 	}
 
@@ -476,7 +650,7 @@ func (sm *SiteManager) GetElementsAttributes(selector string, into *[]map[string
 func (sm *SiteManager) KeyDown(key string, timeoutSec int64, handleError bool) error {
 	action := input.DispatchKeyEvent(input.KeyDown).WithKey(key)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("KeyDown", []interface{}{key}, action)
 		return nil
 	}
 
@@ -489,7 +663,7 @@ func (sm *SiteManager) KeyDown(key string, timeoutSec int64, handleError bool) e
 func (sm *SiteManager) KeyRawDown(key string, timeoutSec int64, handleError bool) error {
 	action := input.DispatchKeyEvent(input.KeyRawDown).WithKey(key)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("KeyRawDown", []interface{}{key}, action)
 		return nil
 	}
 
@@ -502,7 +676,7 @@ func (sm *SiteManager) KeyRawDown(key string, timeoutSec int64, handleError bool
 func (sm *SiteManager) KeyUp(key string, timeoutSec int64, handleError bool) error {
 	action := input.DispatchKeyEvent(input.KeyUp).WithKey(key)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("KeyUp", []interface{}{key}, action)
 		return nil
 	}
 
@@ -515,7 +689,7 @@ func (sm *SiteManager) KeyUp(key string, timeoutSec int64, handleError bool) err
 func (sm *SiteManager) KeyChar(key string, timeoutSec int64, handleError bool) error {
 	action := input.DispatchKeyEvent(input.KeyChar).WithKey(key)
 	if sm.activeGroup != "" {
-		sm.groupActions[sm.activeGroup] = append(sm.groupActions[sm.activeGroup], action)
+		sm.addGroupAction("KeyChar", []interface{}{key}, action)
 		return nil
 	}
 
@@ -538,6 +712,11 @@ func (sm SiteManager) GetTimeoutDurationSecs(secs int64) time.Duration {
 }
 
 func (sm *SiteManager) DoTimeoutContext(timeoutSec int64, handleError bool, action ...chromedp.Action) error {
+	if sm.ctx == nil {
+		sm.Error(errNotStarted, handleError)
+		return errNotStarted
+	}
+
 	if timeoutSec == 0 && sm.timeoutSec > 0 {
 		timeoutSec = sm.timeoutSec
 	}
@@ -550,7 +729,7 @@ func (sm *SiteManager) DoTimeoutContext(timeoutSec int64, handleError bool, acti
 		doCtx = sm.ctx
 	}
 
-	err := chromedp.Run(doCtx, sm.getActions(action...)...)
+	err := sm.run(doCtx, sm.getActions(action...)...)
 
 	sm.Error(err, handleError)
 
@@ -0,0 +1,139 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/mailru/easyjson"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTab answers the commands of the protocol by the json results set for their methods, and records them,
+// to test the actions without a browser. A method without result answers an empty object, a result starting with "!" is an error.
+type fakeTab struct {
+	mu       sync.Mutex
+	results  map[string][]string
+	commands []fakeCommand
+}
+
+type fakeCommand struct {
+	method string
+	params string
+}
+
+func newFakeTab() *fakeTab {
+	return &fakeTab{results: make(map[string][]string)}
+}
+
+// answer sets the results of the method, they are returned in order and the last one is repeated
+func (ft *fakeTab) answer(method string, results ...string) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	ft.results[method] = results
+}
+
+func (ft *fakeTab) Execute(ctx context.Context, method string, params easyjson.Marshaler, res easyjson.Unmarshaler) error {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	command := fakeCommand{method: method}
+	if params != nil {
+		data, err := easyjson.Marshal(params)
+		if err != nil {
+			return err
+		}
+		command.params = string(data)
+	}
+	ft.commands = append(ft.commands, command)
+
+	result := "{}"
+	if results := ft.results[method]; len(results) > 0 {
+		result = results[0]
+		if len(results) > 1 {
+			ft.results[method] = results[1:]
+		}
+	}
+	if strings.HasPrefix(result, "!") {
+		return errors.New(result[1:])
+	}
+	if res == nil {
+		return nil
+	}
+
+	return easyjson.Unmarshal([]byte(result), res)
+}
+
+func (ft *fakeTab) context() context.Context {
+	return cdp.WithExecutor(context.Background(), ft)
+}
+
+// sent returns the params of the commands of the method in order
+func (ft *fakeTab) sent(method string) []string {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	var params []string
+	for _, c := range ft.commands {
+		if c.method == method {
+			params = append(params, c.params)
+		}
+	}
+
+	return params
+}
+
+// browserlessContext is a tab context chromedp.Run accepts without a browser, for the actions not sending any command
+func browserlessContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	ctx, _ = chromedp.NewContext(ctx)
+	c := chromedp.FromContext(ctx)
+	c.Browser = &chromedp.Browser{}
+	c.Target = &chromedp.Target{}
+
+	return ctx
+}
+
+func TestNotStartedSiteManager(t *testing.T) {
+	sm := &SiteManager{}
+
+	var handled error
+	sm.AddErrorHandler(func(err error) {
+		handled = err
+	})
+
+	if err := sm.GoToPath("https://example.com", 0, true); err != errNotStarted {
+		t.Errorf("GoToPath() error = %v, want %v", err, errNotStarted)
+	}
+	if handled != errNotStarted {
+		t.Errorf("handled error = %v, want %v", handled, errNotStarted)
+	}
+
+	if err := sm.runStep(sm.deadline); !errors.Is(err, errNotStarted) {
+		t.Errorf("runStep() error = %v, want %v", err, errNotStarted)
+	}
+}
+
+func TestInitWithoutBrowser(t *testing.T) {
+	var logged []string
+	sm := &SiteManager{config: newConfig(WithLogger(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}))}
+
+	// no chrome is found, the error is logged, Init does not panic without error handler
+	t.Setenv("PATH", t.TempDir())
+	sm.Init(PC, 1, true, false)
+
+	if len(logged) != 1 {
+		t.Errorf("logged %q, want the start error", logged)
+	}
+	if err := sm.GoToPath("https://example.com", 0, false); err != errNotStarted {
+		t.Errorf("GoToPath() error = %v, want %v", err, errNotStarted)
+	}
+}
@@ -0,0 +1,291 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/domstorage"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"time"
+)
+
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// URL can be set instead of Domain when the cookie is set, the domain and path are taken from it
+	URL string `json:"url,omitempty"`
+	// Expires is zero for session cookies
+	Expires  time.Time              `json:"expires"`
+	HTTPOnly bool                   `json:"httpOnly"`
+	Secure   bool                   `json:"secure"`
+	SameSite network.CookieSameSite `json:"sameSite,omitempty"`
+}
+
+func cookieFromNetwork(c *network.Cookie) Cookie {
+	cookie := Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		HTTPOnly: c.HTTPOnly,
+		Secure:   c.Secure,
+		SameSite: c.SameSite,
+	}
+
+	if !c.Session && c.Expires > 0 {
+		cookie.Expires = time.Unix(0, int64(c.Expires*float64(time.Second)))
+	}
+
+	return cookie
+}
+
+func (c Cookie) setAction() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		params := network.SetCookie(c.Name, c.Value).
+			WithURL(c.URL).
+			WithDomain(c.Domain).
+			WithPath(c.Path).
+			WithHTTPOnly(c.HTTPOnly).
+			WithSecure(c.Secure)
+		if c.SameSite != "" {
+			params = params.WithSameSite(c.SameSite)
+		}
+		if !c.Expires.IsZero() {
+			expires := cdp.TimeSinceEpoch(c.Expires)
+			params = params.WithExpires(&expires)
+		}
+
+		ok, err := params.Do(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("browser refused to set cookie %q", c.Name)
+		}
+
+		return nil
+	})
+}
+
+// CookiesInto sets the cookies of the urls into the pointer, or every cookie of the browser if there is no url given
+func (sm *SiteManager) CookiesInto(into *[]Cookie, timeoutSec int64, handleError bool, urls ...string) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		var cookies []*network.Cookie
+		var err error
+		if len(urls) > 0 {
+			cookies, err = network.GetCookies().WithUrls(urls).Do(ctx)
+		} else {
+			cookies, err = network.GetAllCookies().Do(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		*into = nil
+		for _, c := range cookies {
+			*into = append(*into, cookieFromNetwork(c))
+		}
+
+		return nil
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("CookiesInto", []interface{}{urls}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) SetCookie(cookie Cookie, timeoutSec int64, handleError bool) error {
+	action := cookie.setAction()
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SetCookie", []interface{}{cookie.Name, cookie.Domain}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// DeleteCookie deletes the cookies by name, the domain and path narrow it down if they are not empty
+func (sm *SiteManager) DeleteCookie(name, domain, path string, timeoutSec int64, handleError bool) error {
+	action := network.DeleteCookies(name).WithDomain(domain).WithPath(path)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("DeleteCookie", []interface{}{name, domain, path}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) ClearCookies(timeoutSec int64, handleError bool) error {
+	action := network.ClearBrowserCookies()
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ClearCookies", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+type StorageType int
+
+const (
+	LocalStorage StorageType = iota
+	SessionStorage
+)
+
+func (st StorageType) String() string {
+	if st == SessionStorage {
+		return "sessionStorage"
+	}
+
+	return "localStorage"
+}
+
+// storageID returns the storage of the origin, if the origin is empty it is the origin of the current page
+func storageID(ctx context.Context, storage StorageType, origin string) (*domstorage.StorageID, error) {
+	if origin == "" {
+		if err := chromedp.Evaluate(`window.location.origin`, &origin).Do(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := domstorage.Enable().Do(ctx); err != nil {
+		return nil, err
+	}
+
+	return &domstorage.StorageID{SecurityOrigin: origin, IsLocalStorage: storage == LocalStorage}, nil
+}
+
+// StorageItemsInto sets every item of the storage of the origin into the pointer, the empty origin means the current page's.
+// The storage of an origin is reachable only while a page of that origin is open in the tab.
+func (sm *SiteManager) StorageItemsInto(storage StorageType, origin string, into *map[string]string, timeoutSec int64, handleError bool) error {
+	action := storageItems(storage, origin, into)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("StorageItemsInto", []interface{}{storage, origin}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) GetStorageItem(storage StorageType, origin string, key string, into *string, ok *bool, timeoutSec int64, handleError bool) error {
+	var items map[string]string
+	action := chromedp.Tasks{
+		storageItems(storage, origin, &items),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			*into, *ok = items[key]
+			return nil
+		}),
+	}
+	if sm.activeGroup != "" {
+		sm.addGroupAction("GetStorageItem", []interface{}{storage, origin, key}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) SetStorageItem(storage StorageType, origin string, key string, value string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := storageID(ctx, storage, origin)
+		if err != nil {
+			return err
+		}
+
+		return domstorage.SetDOMStorageItem(id, key, value).Do(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SetStorageItem", []interface{}{storage, origin, key, value}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) RemoveStorageItem(storage StorageType, origin string, key string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := storageID(ctx, storage, origin)
+		if err != nil {
+			return err
+		}
+
+		return domstorage.RemoveDOMStorageItem(id, key).Do(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("RemoveStorageItem", []interface{}{storage, origin, key}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (sm *SiteManager) ClearStorage(storage StorageType, origin string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := storageID(ctx, storage, origin)
+		if err != nil {
+			return err
+		}
+
+		return domstorage.Clear(id).Do(ctx)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ClearStorage", []interface{}{storage, origin}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func storageItems(storage StorageType, origin string, into *map[string]string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		id, err := storageID(ctx, storage, origin)
+		if err != nil {
+			return err
+		}
+
+		items, err := domstorage.GetDOMStorageItems(id).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		*into = make(map[string]string)
+		for _, item := range items {
+			if len(item) == 2 {
+				(*into)[item[0]] = item[1]
+			}
+		}
+
+		return nil
+	})
+}
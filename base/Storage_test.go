@@ -0,0 +1,101 @@
+package base
+
+import (
+	"github.com/chromedp/cdproto/network"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCookieFromNetwork(t *testing.T) {
+	tests := []struct {
+		name        string
+		cookie      network.Cookie
+		wantExpires time.Time
+	}{
+		{"session", network.Cookie{Name: "sid", Value: "1", Session: true, Expires: -1}, time.Time{}},
+		// chrome reports -1 as expiry of some session cookies without the session flag
+		{"no expiry", network.Cookie{Name: "sid", Value: "1", Expires: -1}, time.Time{}},
+		{"expiring", network.Cookie{Name: "sid", Value: "1", Expires: 1600000000.5}, time.Unix(1600000000, 5e8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cookie.Domain, tt.cookie.Path = ".example.com", "/"
+			tt.cookie.HTTPOnly, tt.cookie.Secure, tt.cookie.SameSite = true, true, network.CookieSameSiteLax
+
+			got := cookieFromNetwork(&tt.cookie)
+			want := Cookie{Name: "sid", Value: "1", Domain: ".example.com", Path: "/", Expires: tt.wantExpires, HTTPOnly: true, Secure: true, SameSite: network.CookieSameSiteLax}
+			if !got.Expires.Equal(want.Expires) {
+				t.Errorf("cookieFromNetwork() expires %v, want %v", got.Expires, want.Expires)
+			}
+			got.Expires = want.Expires
+			if got != want {
+				t.Errorf("cookieFromNetwork() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestStorageItemActions(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     func(sm *SiteManager)
+		method     string
+		wantParams string
+	}{
+		{"set session item", func(sm *SiteManager) {
+			sm.SetStorageItem(SessionStorage, "https://shop.example", "cart", "3", 0, false)
+		}, "DOMStorage.setDOMStorageItem", `{"storageId":{"securityOrigin":"https://shop.example","isLocalStorage":false},"key":"cart","value":"3"}`},
+		{"remove local item", func(sm *SiteManager) {
+			sm.RemoveStorageItem(LocalStorage, "https://shop.example", "cart", 0, false)
+		}, "DOMStorage.removeDOMStorageItem", `{"storageId":{"securityOrigin":"https://shop.example","isLocalStorage":true},"key":"cart"}`},
+		// the empty origin is the origin of the current page
+		{"clear current origin", func(sm *SiteManager) {
+			sm.ClearStorage(LocalStorage, "", 0, false)
+		}, "DOMStorage.clear", `{"storageId":{"securityOrigin":"https://page.example","isLocalStorage":true}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestManager()
+			sm.Group("storage")
+			tt.record(sm)
+
+			ft := newFakeTab()
+			ft.answer("Runtime.evaluate", `{"result": {"type": "string", "value": "https://page.example"}}`)
+			if err := sm.groupActions["storage"][0].action.Do(ft.context()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ft.sent("DOMStorage.enable")) != 1 {
+				t.Error("the DOMStorage domain is not enabled")
+			}
+			if got := ft.sent(tt.method); len(got) != 1 || got[0] != tt.wantParams {
+				t.Errorf("%s sent %q, want %s", tt.method, got, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestGetStorageItem(t *testing.T) {
+	sm := newTestManager()
+	sm.Group("storage")
+
+	var value string
+	var ok bool
+	sm.GetStorageItem(LocalStorage, "https://shop.example", "cart", &value, &ok, 0, false)
+
+	ft := newFakeTab()
+	// an item not of a key and a value is skipped
+	ft.answer("DOMStorage.getDOMStorageItems", `{"entries": [["cart", "3"], ["broken"], ["theme", "dark"]]}`)
+	if err := sm.groupActions["storage"][0].action.Do(ft.context()); err != nil {
+		t.Fatal(err)
+	}
+	if value != "3" || !ok {
+		t.Errorf("GetStorageItem() = %q, %v, want 3, true", value, ok)
+	}
+	if got := ft.sent("DOMStorage.getDOMStorageItems"); len(got) != 1 || !strings.Contains(got[0], `"securityOrigin":"https://shop.example","isLocalStorage":true`) {
+		t.Errorf("items asked by %q", got)
+	}
+}
@@ -0,0 +1,345 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"sync"
+)
+
+type Tab struct {
+	ID    string
+	URL   string
+	Title string
+	// Active tells if the actions of the SiteManager run in this tab
+	Active bool
+}
+
+type tabContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// handlers are the names of the event handlers listening on the tab
+	handlers map[string]bool
+	// ready is closed when the tab is attached, err tells why it could not be
+	ready chan struct{}
+	err   error
+}
+
+// tabSet keeps the tab the SiteManager started on and the tabs and popups opened from them.
+// The events of the browser update it and attach the opened tabs, so it is guarded by the mutex.
+type tabSet struct {
+	mu       sync.Mutex
+	main     target.ID
+	active   target.ID
+	contexts map[target.ID]*tabContext
+	known    map[target.ID]bool
+	// opened are the new tabs not returned by WaitNewTab yet
+	opened []target.ID
+	// changed is closed and replaced when a tab opens
+	changed chan struct{}
+	// onOpen is called in a new goroutine with the tabs opened from the tabs of the set
+	onOpen func(id target.ID)
+}
+
+func newTabSet(id target.ID, ctx context.Context) *tabSet {
+	ready := make(chan struct{})
+	close(ready)
+
+	return &tabSet{
+		main:     id,
+		active:   id,
+		contexts: map[target.ID]*tabContext{id: {ctx: ctx, handlers: make(map[string]bool), ready: ready}},
+		known:    map[target.ID]bool{id: true},
+		changed:  make(chan struct{}),
+	}
+}
+
+func (ts *tabSet) onTargetEvent(ev interface{}) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *target.EventTargetCreated:
+		info := e.TargetInfo
+		// only the tabs opened by our tabs belong to the SiteManager
+		if info.Type != "page" || !ts.known[info.OpenerID] || ts.known[info.TargetID] {
+			return
+		}
+		ts.known[info.TargetID] = true
+		ts.opened = append(ts.opened, info.TargetID)
+		close(ts.changed)
+		ts.changed = make(chan struct{})
+		if ts.onOpen != nil {
+			go ts.onOpen(info.TargetID)
+		}
+
+	case *target.EventTargetDestroyed:
+		delete(ts.known, e.TargetID)
+		for i, id := range ts.opened {
+			if id == e.TargetID {
+				ts.opened = append(ts.opened[:i], ts.opened[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// next returns the oldest tab opened and not returned yet, or the channel closed when a tab opens
+func (ts *tabSet) next() (target.ID, <-chan struct{}) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if len(ts.opened) == 0 {
+		return "", ts.changed
+	}
+
+	id := ts.opened[0]
+	ts.opened = ts.opened[1:]
+
+	return id, nil
+}
+
+func (ts *tabSet) isKnown(id target.ID) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.known[id]
+}
+
+// watchTabs follows the tabs opened from the tab of the context
+func (sm *SiteManager) watchTabs(ctx context.Context) error {
+	c := chromedp.FromContext(ctx)
+	if c == nil || c.Target == nil {
+		return errors.New("the tab of the SiteManager is not started")
+	}
+
+	sm.tabs = newTabSet(c.Target.TargetID, ctx)
+	// the tabs are attached when they open, so the handlers of the SiteManager (closing the dialogs, ...) work in them before they are switched to
+	sm.tabs.onOpen = func(id target.ID) {
+		if _, err := sm.attachTab(id); err != nil {
+			sm.logf("%v", err)
+		}
+	}
+	chromedp.ListenBrowser(ctx, sm.tabs.onTargetEvent)
+
+	browserCtx, err := browserContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return target.SetDiscoverTargets(true).Do(browserCtx)
+}
+
+// MainTab returns the id of the tab the SiteManager started on
+func (sm SiteManager) MainTab() string {
+	return string(sm.tabs.main)
+}
+
+// ActiveTab returns the id of the tab the actions run in
+func (sm SiteManager) ActiveTab() string {
+	return string(sm.tabs.active)
+}
+
+// WaitNewTab waits for a tab or popup opened by the page (a target=_blank link, window.open, ...) and sets its id into the pointer.
+// The tabs are returned in the order they opened, one tab once, so the tab opened before calling it is returned too.
+// It does not switch to the tab, call SwitchTab for that.
+func (sm *SiteManager) WaitNewTab(into *string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		for {
+			id, changed := sm.tabs.next()
+			if id != "" {
+				*into = string(id)
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("no new tab opened: %v", ctx.Err())
+			case <-changed:
+			}
+		}
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("WaitNewTab", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// TabsInto sets the open tabs of the SiteManager into the pointer: the main tab and the ones opened from it
+func (sm *SiteManager) TabsInto(into *[]Tab, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		browserCtx, err := browserContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		infos, err := target.GetTargets().Do(browserCtx)
+		if err != nil {
+			return err
+		}
+
+		*into = nil
+		for _, info := range infos {
+			if info.Type != "page" || !sm.tabs.isKnown(info.TargetID) {
+				continue
+			}
+
+			*into = append(*into, Tab{
+				ID:     string(info.TargetID),
+				URL:    info.URL,
+				Title:  info.Title,
+				Active: info.TargetID == sm.tabs.active,
+			})
+		}
+
+		return nil
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("TabsInto", nil, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// SwitchTab makes the following actions run in the tab.
+// The event handlers of the SiteManager (console, intercepts, har, ...) follow it, but the intercepts and the har
+// recording have to be started again in the tab to enable them in the browser.
+func (sm *SiteManager) SwitchTab(id string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		return sm.useTab(target.ID(id))
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("SwitchTab", []interface{}{id}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// CloseTab closes the tab, the main tab is closed by Cancel only. If the active tab is closed the main tab becomes active.
+func (sm *SiteManager) CloseTab(id string, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		return sm.closeTab(ctx, target.ID(id))
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("CloseTab", []interface{}{id}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// GroupProcessInTab processes the group in the tab, then switches back to the tab active before
+func (sm *SiteManager) GroupProcessInTab(id string, group string, timeoutSecs int64, handleError bool) (*GroupResult, error) {
+	previous := sm.tabs.active
+
+	if err := sm.useTab(target.ID(id)); err != nil {
+		sm.Error(err, handleError)
+		return nil, err
+	}
+	defer func() {
+		if err := sm.useTab(previous); err != nil {
+			sm.logf("could not switch back to tab %s: %v", previous, err)
+		}
+	}()
+
+	return sm.GroupProcess(group, timeoutSecs, handleError)
+}
+
+// useTab attaches to the tab if it is not attached yet, then the actions and event handlers use its context
+func (sm *SiteManager) useTab(id target.ID) error {
+	if !sm.tabs.isKnown(id) {
+		return fmt.Errorf("tab %s is not open in the SiteManager", id)
+	}
+
+	tc, err := sm.attachTab(id)
+	if err != nil {
+		return err
+	}
+
+	sm.tabs.mu.Lock()
+	sm.tabs.active = id
+	sm.listenTab(tc)
+	sm.tabs.mu.Unlock()
+
+	sm.tabCtx = tc.ctx
+	sm.useDeadline()
+
+	return nil
+}
+
+// attachTab attaches to the tab once, the event handlers are registered before, so the events sent while attaching are handled too
+func (sm *SiteManager) attachTab(id target.ID) (*tabContext, error) {
+	sm.tabs.mu.Lock()
+	tc, ok := sm.tabs.contexts[id]
+	if !ok {
+		ctx, cancel := chromedp.NewContext(sm.tabs.contexts[sm.tabs.main].ctx, chromedp.WithTargetID(id))
+		tc = &tabContext{ctx: ctx, cancel: cancel, handlers: make(map[string]bool), ready: make(chan struct{})}
+		sm.tabs.contexts[id] = tc
+		sm.listenTab(tc)
+	}
+	sm.tabs.mu.Unlock()
+
+	if ok {
+		<-tc.ready
+		return tc, tc.err
+	}
+
+	if err := chromedp.Run(tc.ctx); err != nil {
+		tc.err = fmt.Errorf("could not attach to tab %s: %v", id, err)
+		sm.tabs.mu.Lock()
+		delete(sm.tabs.contexts, id)
+		sm.tabs.mu.Unlock()
+		tc.cancel()
+	}
+	close(tc.ready)
+
+	return tc, tc.err
+}
+
+func (sm *SiteManager) closeTab(ctx context.Context, id target.ID) error {
+	if id == sm.tabs.main {
+		return errors.New("the main tab can not be closed, call Cancel")
+	}
+
+	sm.tabs.mu.Lock()
+	tc, ok := sm.tabs.contexts[id]
+	delete(sm.tabs.contexts, id)
+	sm.tabs.mu.Unlock()
+
+	if ok {
+		// cancelling the context of an attached tab closes it
+		tc.cancel()
+	} else {
+		browserCtx, err := browserContext(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := target.CloseTarget(id).Do(browserCtx); err != nil {
+			return err
+		}
+	}
+
+	if sm.tabs.active == id {
+		return sm.useTab(sm.tabs.main)
+	}
+
+	return nil
+}
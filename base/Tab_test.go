@@ -0,0 +1,105 @@
+package base
+
+import (
+	"context"
+	"github.com/chromedp/cdproto/target"
+	"testing"
+	"time"
+)
+
+func TestUseTabKeepsDeadline(t *testing.T) {
+	p := newTestPool(1)
+	defer p.Close()
+
+	sm, err := p.Acquire(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm.tabs = newTabSet("main", context.Background())
+	sm.tabCtx = context.Background()
+	sm.timeoutSec = 60
+	sm.startTimeout()
+
+	deadline, cancels := sm.deadline, len(sm.cancel)
+	if deadline.IsZero() {
+		t.Fatal("startTimeout() set no deadline")
+	}
+
+	// switching tabs keeps the deadline of the session
+	for i := 0; i < 5; i++ {
+		if err := sm.useTab("main"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sm.deadline != deadline || len(sm.cancel) != cancels {
+		t.Errorf("the session changed: deadline %v with %d cancel funcs, want %v with %d", sm.deadline, len(sm.cancel), deadline, cancels)
+	}
+	if ctxDeadline, ok := sm.ctx.Deadline(); !ok || !ctxDeadline.Equal(deadline) {
+		t.Errorf("the context of the actions ends at %v, want %v", ctxDeadline, deadline)
+	}
+
+	if err := sm.useTab("other"); err == nil {
+		t.Error("useTab() of an unknown tab succeeded, want error")
+	}
+
+	// the next lease is a new session with its own deadline
+	time.Sleep(10 * time.Millisecond)
+	p.Release(sm)
+	if sm, err = p.Acquire(0); err != nil {
+		t.Fatal(err)
+	}
+	if !sm.deadline.After(deadline) {
+		t.Errorf("the next lease has deadline %v, want later than %v", sm.deadline, deadline)
+	}
+	if ctxDeadline, ok := sm.ctx.Deadline(); !ok || !ctxDeadline.Equal(sm.deadline) {
+		t.Errorf("the context of the next lease ends at %v, want %v", ctxDeadline, sm.deadline)
+	}
+}
+
+func TestNoTimeout(t *testing.T) {
+	sm := newTestManager()
+	sm.tabCtx = context.Background()
+	sm.startTimeout()
+
+	if !sm.deadline.IsZero() || sm.ctx != sm.tabCtx {
+		t.Errorf("without timeout the session has deadline %v", sm.deadline)
+	}
+}
+
+func TestTabSetOpened(t *testing.T) {
+	ts := newTabSet("main", context.Background())
+	attached := make(chan target.ID, 4)
+	ts.onOpen = func(id target.ID) {
+		attached <- id
+	}
+
+	events := []interface{}{
+		&target.EventTargetCreated{TargetInfo: &target.Info{TargetID: "popup", Type: "page", OpenerID: "main"}},
+		// a tab of another SiteManager or a worker is not ours
+		&target.EventTargetCreated{TargetInfo: &target.Info{TargetID: "other", Type: "page", OpenerID: "elsewhere"}},
+		&target.EventTargetCreated{TargetInfo: &target.Info{TargetID: "worker", Type: "service_worker", OpenerID: "main"}},
+		&target.EventTargetCreated{TargetInfo: &target.Info{TargetID: "nested", Type: "page", OpenerID: "popup"}},
+		&target.EventTargetDestroyed{TargetID: "nested"},
+	}
+	for _, ev := range events {
+		ts.onTargetEvent(ev)
+	}
+
+	// the popups are attached when they open, so their dialogs are handled before they are switched to
+	got := map[target.ID]bool{<-attached: true, <-attached: true}
+	if !got["popup"] || !got["nested"] {
+		t.Errorf("attached tabs %v, want popup and nested", got)
+	}
+	select {
+	case id := <-attached:
+		t.Errorf("tab %s is attached, it is not ours", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if id, _ := ts.next(); id != "popup" {
+		t.Errorf("next() = %q, want popup", id)
+	}
+	if id, _ := ts.next(); id != "" || ts.isKnown("nested") {
+		t.Errorf("next() = %q after the nested popup closed", id)
+	}
+}
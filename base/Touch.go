@@ -0,0 +1,204 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"math"
+	"time"
+)
+
+const (
+	// touchSteps is the count of touch moves of a gesture, touchInterval is waited between them like a finger moving for about a quarter second
+	touchSteps    = 15
+	touchInterval = 16 * time.Millisecond
+	// pinchDistance is the distance of the two fingers when a pinch starts
+	pinchDistance = 100.0
+)
+
+// touchPath is the way of one finger during a gesture, in the coordinates of the viewport
+type touchPath struct {
+	fromX, fromY float64
+	toX, toY     float64
+}
+
+// Tap touches the middle of the element and lifts the finger, the page gets the touch events then the emulated mouse click
+func (sm *SiteManager) Tap(selector string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return pressObject(ctx, el, 0)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("Tap", []interface{}{selector}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// LongPress touches the middle of the element and holds the finger for the duration, like opening the context menu on a phone
+func (sm *SiteManager) LongPress(selector string, duration time.Duration, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return pressObject(ctx, el, duration)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("LongPress", []interface{}{selector, duration}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// Swipe touches the middle of the element and moves the finger by the distance, like swiping a carousel (negative distanceX swipes to the left)
+func (sm *SiteManager) Swipe(selector string, distanceX float64, distanceY float64, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		x, y, err := elementCenter(ctx, el)
+		if err != nil {
+			return err
+		}
+
+		return touchGesture(ctx, touchPath{x, y, x + distanceX, y + distanceY})
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("Swipe", []interface{}{selector, distanceX, distanceY}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// ScrollBySwipe scrolls the page by the distance with a finger moving the opposite way over the middle of the viewport,
+// positive distanceY scrolls down. The distance has to fit into the viewport, the page may scroll further by the momentum.
+func (sm *SiteManager) ScrollBySwipe(distanceX float64, distanceY float64, timeoutSec int64, handleError bool) error {
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		viewport, _, _, err := page.GetLayoutMetrics().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		width, height := float64(viewport.ClientWidth), float64(viewport.ClientHeight)
+		if math.Abs(distanceX) >= width || math.Abs(distanceY) >= height {
+			return fmt.Errorf("the distance %.0fx%.0f does not fit into the viewport of %.0fx%.0f", distanceX, distanceY, width, height)
+		}
+
+		x, y := width/2, height/2
+
+		return touchGesture(ctx, touchPath{x + distanceX/2, y + distanceY/2, x - distanceX/2, y - distanceY/2})
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("ScrollBySwipe", []interface{}{distanceX, distanceY}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// Pinch moves two fingers apart or together over the middle of the element, the scale is the ratio of their distance at the end and the start,
+// above 1 zooms in and below 1 zooms out. The page zooms only if the device is emulated as mobile.
+func (sm *SiteManager) Pinch(selector string, scale float64, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		if scale <= 0 {
+			return fmt.Errorf("invalid pinch scale %v", scale)
+		}
+
+		x, y, err := elementCenter(ctx, el)
+		if err != nil {
+			return err
+		}
+
+		return touchGesture(ctx, pinchPaths(x, y, scale)...)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("Pinch", []interface{}{selector, scale}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// pressObject touches the middle of the element for the duration
+func pressObject(ctx context.Context, el *runtime.RemoteObject, duration time.Duration) error {
+	x, y, err := elementCenter(ctx, el)
+	if err != nil {
+		return err
+	}
+
+	if err := input.DispatchTouchEvent(input.TouchStart, []*input.TouchPoint{{X: x, Y: y}}).Do(ctx); err != nil {
+		return err
+	}
+
+	if duration > 0 {
+		if err := sleep(ctx, duration); err != nil {
+			return err
+		}
+	}
+
+	return input.DispatchTouchEvent(input.TouchEnd, []*input.TouchPoint{}).Do(ctx)
+}
+
+// pinchPaths are the ways of two fingers on a horizontal line through the point, moving from pinchDistance apart to scale times of it
+func pinchPaths(x float64, y float64, scale float64) []touchPath {
+	from, to := pinchDistance/2, pinchDistance*scale/2
+
+	return []touchPath{
+		{x - from, y, x - to, y},
+		{x + from, y, x + to, y},
+	}
+}
+
+// touchGesture moves a finger along every path at the same time
+func touchGesture(ctx context.Context, paths ...touchPath) error {
+	points := func(progress float64) []*input.TouchPoint {
+		var tps []*input.TouchPoint
+		for i, p := range paths {
+			tps = append(tps, &input.TouchPoint{
+				X:  p.fromX + (p.toX-p.fromX)*progress,
+				Y:  p.fromY + (p.toY-p.fromY)*progress,
+				ID: float64(i),
+			})
+		}
+		return tps
+	}
+
+	if err := input.DispatchTouchEvent(input.TouchStart, points(0)).Do(ctx); err != nil {
+		return err
+	}
+
+	for i := 1; i <= touchSteps; i++ {
+		if err := sleep(ctx, touchInterval); err != nil {
+			return err
+		}
+		if err := input.DispatchTouchEvent(input.TouchMove, points(float64(i)/touchSteps)).Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	return input.DispatchTouchEvent(input.TouchEnd, []*input.TouchPoint{}).Do(ctx)
+}
+
+// sleep waits for the duration, or returns the error of the context if it ends earlier
+func sleep(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(duration):
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package base
+
+import (
+	"encoding/json"
+	"github.com/chromedp/cdproto/runtime"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type touchEvent struct {
+	Type   string `json:"type"`
+	Points []struct {
+		X  float64 `json:"x"`
+		Y  float64 `json:"y"`
+		ID float64 `json:"id"`
+	} `json:"touchPoints"`
+}
+
+// touchEvents returns the touch events dispatched into the fake tab in order
+func touchEvents(t *testing.T, ft *fakeTab) []touchEvent {
+	t.Helper()
+
+	var events []touchEvent
+	for _, params := range ft.sent("Input.dispatchTouchEvent") {
+		var e touchEvent
+		if err := json.Unmarshal([]byte(params), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	return events
+}
+
+// checkGesture checks the events of a gesture: a start, touchSteps moves and an end, every finger going along its path in even steps
+func checkGesture(t *testing.T, events []touchEvent, paths ...touchPath) {
+	t.Helper()
+
+	if len(events) != touchSteps+2 {
+		t.Fatalf("%d touch events, want %d", len(events), touchSteps+2)
+	}
+	if events[0].Type != "touchStart" || events[len(events)-1].Type != "touchEnd" || len(events[len(events)-1].Points) != 0 {
+		t.Errorf("gesture from %s to %s %+v", events[0].Type, events[len(events)-1].Type, events[len(events)-1].Points)
+	}
+
+	for i, e := range events[:touchSteps+1] {
+		if i > 0 && e.Type != "touchMove" {
+			t.Errorf("event %d is %s, want touchMove", i, e.Type)
+		}
+		if len(e.Points) != len(paths) {
+			t.Fatalf("event %d touches %d points, want %d", i, len(e.Points), len(paths))
+		}
+
+		progress := float64(i) / touchSteps
+		for finger, p := range paths {
+			point := e.Points[finger]
+			wantX, wantY := p.fromX+(p.toX-p.fromX)*progress, p.fromY+(p.toY-p.fromY)*progress
+			if point.ID != float64(finger) || math.Abs(point.X-wantX) > 1e-9 || math.Abs(point.Y-wantY) > 1e-9 {
+				t.Errorf("event %d finger %d = %+v, want (%v, %v)", i, finger, point, wantX, wantY)
+			}
+		}
+	}
+}
+
+func TestTouchGestureSwipe(t *testing.T) {
+	ft := newFakeTab()
+
+	swipe := touchPath{200, 300, 50, 300}
+	if err := touchGesture(ft.context(), swipe); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGesture(t, touchEvents(t, ft), swipe)
+}
+
+func TestPinchPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		scale float64
+		want  []touchPath
+	}{
+		{"zoom in", 2, []touchPath{{150, 80, 100, 80}, {250, 80, 300, 80}}},
+		{"zoom out", 0.5, []touchPath{{150, 80, 175, 80}, {250, 80, 225, 80}}},
+		{"still", 1, []touchPath{{150, 80, 150, 80}, {250, 80, 250, 80}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := pinchPaths(200, 80, tt.scale)
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("pinchPaths(200, 80, %v) = %+v, want %+v", tt.scale, paths, tt.want)
+			}
+
+			ft := newFakeTab()
+			if err := touchGesture(ft.context(), paths...); err != nil {
+				t.Fatal(err)
+			}
+			checkGesture(t, touchEvents(t, ft), paths...)
+		})
+	}
+}
+
+func TestScrollBySwipe(t *testing.T) {
+	tests := []struct {
+		name      string
+		distanceX float64
+		distanceY float64
+		want      *touchPath
+	}{
+		// the finger moves up over the middle of the viewport to scroll down
+		{"down", 0, 300, &touchPath{200, 450, 200, 150}},
+		{"left", -100, 0, &touchPath{150, 300, 250, 300}},
+		{"too far", 0, 600, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestManager()
+			sm.Group("touch")
+			sm.ScrollBySwipe(tt.distanceX, tt.distanceY, 0, false)
+
+			ft := newFakeTab()
+			ft.answer("Page.getLayoutMetrics", layoutMetrics(0, 400, 600, 2000))
+
+			err := sm.groupActions["touch"][0].action.Do(ft.context())
+			if tt.want == nil {
+				if err == nil || len(ft.sent("Input.dispatchTouchEvent")) != 0 {
+					t.Errorf("ScrollBySwipe() error = %v, %d touch events, want an error only", err, len(ft.sent("Input.dispatchTouchEvent")))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGesture(t, touchEvents(t, ft), *tt.want)
+		})
+	}
+}
+
+func TestPressObject(t *testing.T) {
+	ft := newFakeTab()
+	ft.answer("Runtime.callFunctionOn", `{"result": {"type": "undefined"}}`)
+	ft.answer("DOM.getContentQuads", `{"quads": [[10, 20, 30, 20, 30, 40, 10, 40]]}`)
+
+	if err := pressObject(ft.context(), &runtime.RemoteObject{ObjectID: "1"}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	events := touchEvents(t, ft)
+	if len(events) != 2 || events[0].Type != "touchStart" || events[1].Type != "touchEnd" {
+		t.Fatalf("touch events = %+v, want a tap", events)
+	}
+	if p := events[0].Points; len(p) != 1 || p[0].X != 20 || p[0].Y != 30 {
+		t.Errorf("tapped %+v, want (20, 30)", p)
+	}
+}
@@ -0,0 +1,198 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileChooserWaiter passes the file chooser opened in the tab to the UploadFilesByChooser waiting for it
+type fileChooserWaiter struct {
+	mu     sync.Mutex
+	opened chan *page.EventFileChooserOpened
+}
+
+func (fw *fileChooserWaiter) wait() <-chan *page.EventFileChooserOpened {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.opened = make(chan *page.EventFileChooserOpened, 1)
+
+	return fw.opened
+}
+
+func (fw *fileChooserWaiter) stop() {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.opened = nil
+}
+
+func (fw *fileChooserWaiter) notify(e *page.EventFileChooserOpened) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.opened == nil {
+		return false
+	}
+
+	select {
+	case fw.opened <- e:
+	default:
+	}
+
+	return true
+}
+
+// UploadFiles attaches the files to the file input of the selector, the input and change events are fired by the browser.
+// The paths are made absolute, with a browser started by NewRemote they are passed as they are and have to exist on the machine of the browser.
+func (sm *SiteManager) UploadFiles(selector string, paths []string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	files, err := sm.uploadPaths(paths)
+	if err != nil {
+		sm.Error(err, handleError)
+		return err
+	}
+
+	action := nodeAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return setInputFiles(ctx, el, files)
+	}, options...)
+	if sm.activeGroup != "" {
+		sm.addGroupAction("UploadFiles", []interface{}{selector, paths}, action)
+		return nil
+	}
+
+	err = sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// UploadFilesByChooser clicks the element of the trigger selector, like a custom upload button opening the file chooser dialog,
+// then answers the dialog by the files instead of showing it
+func (sm *SiteManager) UploadFilesByChooser(trigger string, paths []string, timeoutSec int64, handleError bool, options ...chromedp.QueryOption) error {
+	files, err := sm.uploadPaths(paths)
+	if err != nil {
+		sm.Error(err, handleError)
+		return err
+	}
+
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		sm.listen("fileChooser", sm.onFileChooserEvent)
+
+		opened := sm.fileChooser.wait()
+		defer sm.fileChooser.stop()
+
+		if err := page.SetInterceptFileChooserDialog(true).Do(ctx); err != nil {
+			return err
+		}
+		defer func() {
+			if err := page.SetInterceptFileChooserDialog(false).Do(ctx); err != nil {
+				sm.logf("could not stop intercepting the file chooser: %v", err)
+			}
+		}()
+
+		if err := chromedp.Click(trigger, options...).Do(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no file chooser opened by %s: %v", trigger, ctx.Err())
+		case e := <-opened:
+			if e.Mode == page.FileChooserOpenedModeSelectSingle && len(files) > 1 {
+				return fmt.Errorf("the file chooser opened by %s takes one file, got %d", trigger, len(files))
+			}
+
+			return dom.SetFileInputFiles(files).WithBackendNodeID(e.BackendNodeID).Do(ctx)
+		}
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("UploadFilesByChooser", []interface{}{trigger, paths}, action)
+		return nil
+	}
+
+	err = sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+func (f *FrameScope) UploadFiles(selector string, paths []string, timeoutSec int64, handleError bool) error {
+	files, err := f.sm.uploadPaths(paths)
+	if err != nil {
+		f.sm.Error(err, handleError)
+		return err
+	}
+
+	action := f.presentAction(selector, func(ctx context.Context, el *runtime.RemoteObject) error {
+		return setInputFiles(ctx, el, files)
+	})
+
+	return f.do("UploadFiles", []interface{}{selector, paths}, action, timeoutSec, handleError)
+}
+
+func (sm *SiteManager) onFileChooserEvent(ctx context.Context, ev interface{}) {
+	e, ok := ev.(*page.EventFileChooserOpened)
+	if !ok {
+		return
+	}
+
+	if !sm.fileChooser.notify(e) {
+		sm.logf("file chooser opened in frame %s while no upload was waiting for it", e.FrameID)
+	}
+}
+
+func setInputFiles(ctx context.Context, el *runtime.RemoteObject, files []string) error {
+	var isFileInput bool
+	if err := callOn(ctx, el, `function() { return this.tagName === 'INPUT' && this.type === 'file'; }`, &isFileInput); err != nil {
+		return err
+	}
+	if !isFileInput {
+		return errors.New("the element is not a file input")
+	}
+
+	if err := dom.SetFileInputFiles(files).WithObjectID(el.ObjectID).Do(ctx); err != nil {
+		return err
+	}
+
+	var count int
+	if err := callOn(ctx, el, `function() { return this.files.length; }`, &count); err != nil {
+		return err
+	}
+	if count != len(files) {
+		return fmt.Errorf("the file input has %d file(s) instead of %d", count, len(files))
+	}
+
+	return nil
+}
+
+// uploadPaths makes the paths absolute, the browser does not run in the working directory of the tests
+func (sm SiteManager) uploadPaths(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no file to upload")
+	}
+	if sm.config.remoteURL != "" {
+		return paths, nil
+	}
+
+	var files []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, fmt.Errorf("could not upload file: %v", err)
+		}
+		files = append(files, abs)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,125 @@
+package base
+
+import (
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUploadPaths(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "avatar.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		name    string
+		remote  string
+		paths   []string
+		want    []string
+		wantErr bool
+	}{
+		{"relative", "", []string{"avatar.png"}, []string{filepath.Join(dir, "avatar.png")}, false},
+		{"absolute", "", []string{filepath.Join(dir, "avatar.png")}, []string{filepath.Join(dir, "avatar.png")}, false},
+		{"missing", "", []string{"avatar.png", "missing.png"}, nil, true},
+		{"none", "", nil, nil, true},
+		// the files of a remote browser are on its machine, they are not checked here
+		{"remote", "ws://chrome:9222/devtools/browser/1", []string{"uploads/missing.png"}, []string{"uploads/missing.png"}, false},
+		{"remote none", "ws://chrome:9222/devtools/browser/1", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestManager()
+			sm.config.remoteURL = tt.remote
+
+			got, err := sm.uploadPaths(tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("uploadPaths(%v) error = %v, wantErr %v", tt.paths, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uploadPaths(%v) = %v, want %v", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetInputFiles(t *testing.T) {
+	files := []string{"/tmp/a.png", "/tmp/b.png"}
+
+	tests := []struct {
+		name    string
+		answers []string
+		wantSet bool
+		wantErr string
+	}{
+		{"set", []string{remoteValue(`true`), remoteValue(`2`)}, true, ""},
+		{"not a file input", []string{remoteValue(`false`)}, false, "the element is not a file input"},
+		// a single file input keeps one file only
+		{"fewer files", []string{remoteValue(`true`), remoteValue(`1`)}, true, "the file input has 1 file(s) instead of 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := newFakeTab()
+			ft.answer("Runtime.callFunctionOn", tt.answers...)
+
+			err := setInputFiles(ft.context(), &runtime.RemoteObject{ObjectID: "7"}, files)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("setInputFiles() error = %v, want %q", err, tt.wantErr)
+			}
+
+			set := ft.sent("DOM.setFileInputFiles")
+			if (len(set) == 1) != tt.wantSet {
+				t.Fatalf("files set by %q", set)
+			}
+			if tt.wantSet && set[0] != `{"files":["/tmp/a.png","/tmp/b.png"],"objectId":"7"}` {
+				t.Errorf("files set by %s", set[0])
+			}
+		})
+	}
+}
+
+func TestFileChooserWaiter(t *testing.T) {
+	fw := &fileChooserWaiter{}
+	event := &page.EventFileChooserOpened{FrameID: "main", Mode: page.FileChooserOpenedModeSelectMultiple}
+
+	if fw.notify(event) {
+		t.Error("notify() without a waiting upload = true")
+	}
+
+	opened := fw.wait()
+	if !fw.notify(event) || !fw.notify(event) {
+		t.Error("notify() of a waiting upload = false")
+	}
+	if e := <-opened; e != event {
+		t.Errorf("the upload got %+v", e)
+	}
+	// the second chooser is dropped, the upload takes one
+	if len(opened) != 0 {
+		t.Errorf("%d more choosers passed", len(opened))
+	}
+
+	fw.stop()
+	if fw.notify(event) {
+		t.Error("notify() after stop() = true")
+	}
+}
+
+func TestUploadFilesWithoutFiles(t *testing.T) {
+	sm := newTestManager()
+	sm.Group("upload")
+
+	// the paths are checked when the action is recorded, not when the group runs
+	if err := sm.UploadFiles("#avatar", []string{"missing.png"}, 0, false); err == nil || !strings.Contains(err.Error(), "could not upload file") {
+		t.Errorf("UploadFiles() error = %v", err)
+	}
+	if len(sm.groupActions["upload"]) != 0 {
+		t.Errorf("%d actions recorded, want none", len(sm.groupActions["upload"]))
+	}
+}
@@ -0,0 +1,275 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/chromedp/chromedp"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type VisualOptions struct {
+	// ScreenshotOptions choose the captured area and the masked elements, the format is always png
+	ScreenshotOptions
+	// Tolerance is the largest difference of a color channel (0-255) of two pixels still counted as equal, against the antialiasing noise
+	Tolerance uint8
+	// Threshold is the ratio of different pixels (0-1) still accepted, zero fails on any different pixel
+	Threshold float64
+	// Ignore are the regions of the screenshot not compared, in the pixels of the screenshot
+	Ignore []image.Rectangle
+}
+
+// VisualDiff is the result of comparing a screenshot to its baseline
+type VisualDiff struct {
+	Pixels    int
+	Different int
+	// Image shows the baseline faded, with the different pixels red
+	Image *image.NRGBA
+}
+
+func (vd VisualDiff) Ratio() float64 {
+	if vd.Pixels == 0 {
+		return 0
+	}
+
+	return float64(vd.Different) / float64(vd.Pixels)
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SetVisualBaselines sets the directory of the baseline images of AssertScreenshot.
+// In update mode the screenshots are saved as the new baselines instead of comparing them, the baselines are written only in this mode.
+func (sm *SiteManager) SetVisualBaselines(dir string, update bool) {
+	sm.baselineDir = dir
+	sm.updateBaselines = update
+}
+
+// AssertScreenshot compares a screenshot to its baseline and fails like the other assertions if more pixels differ than the threshold.
+// The baseline is the file <baseline dir>/<group>/<name>/<device name>.png, the group is "default" outside of groups.
+// A missing baseline fails the assertion, the update mode of SetVisualBaselines creates it. On failure the screenshot and the diff image
+// are saved next to the baseline as .actual.png and .diff.png.
+func (sm *SiteManager) AssertScreenshot(name string, opts VisualOptions, timeoutSec int64, handleError bool) error {
+	group := sm.activeGroup
+	if group == "" {
+		group = "default"
+	}
+	opts.Format = ScreenshotPNG
+
+	action := chromedp.ActionFunc(func(ctx context.Context) error {
+		if sm.baselineDir == "" {
+			return errors.New("no baseline directory, call SetVisualBaselines first")
+		}
+
+		shot, err := sm.captureScreenshot(ctx, opts.ScreenshotOptions)
+		if err != nil {
+			return err
+		}
+		sm.attachScreenshot(shot)
+
+		path := filepath.Join(sm.baselineDir, pathName(group), pathName(name), pathName(sm.info.Device().Name)+".png")
+		actualPath := strings.TrimSuffix(path, ".png") + ".actual.png"
+		diffPath := strings.TrimSuffix(path, ".png") + ".diff.png"
+
+		if sm.updateBaselines {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			sm.logf("saving visual baseline %s", path)
+			removeFiles(actualPath, diffPath)
+
+			return ioutil.WriteFile(path, shot, 0644)
+		}
+
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(actualPath, shot, 0644); err != nil {
+				return err
+			}
+
+			return sm.assertFailed(AssertionError{
+				Assertion: "AssertScreenshot",
+				Subject:   path,
+				Matcher:   MatchEqual,
+				Expected:  "baseline image",
+				Actual:    fmt.Sprintf("missing baseline, screenshot in %s", actualPath),
+			})
+		}
+
+		baseline, err := readPNG(path)
+		if err != nil {
+			return err
+		}
+		actual, err := png.Decode(bytes.NewReader(shot))
+		if err != nil {
+			return err
+		}
+
+		ae := AssertionError{
+			Assertion: "AssertScreenshot",
+			Subject:   path,
+			Matcher:   MatchAtMost,
+			Expected:  fmt.Sprintf("%.4f%%", opts.Threshold*100),
+		}
+
+		if baseline.Bounds().Size() != actual.Bounds().Size() {
+			ae.Matcher = MatchEqual
+			ae.Expected = fmt.Sprintf("size %v", baseline.Bounds().Size())
+			ae.Actual = fmt.Sprintf("size %v, screenshot in %s", actual.Bounds().Size(), actualPath)
+			if err := ioutil.WriteFile(actualPath, shot, 0644); err != nil {
+				return err
+			}
+
+			return sm.assertFailed(ae)
+		}
+
+		diff := CompareImages(baseline, actual, opts.Tolerance, opts.Ignore)
+		if diff.Ratio() <= opts.Threshold {
+			removeFiles(actualPath, diffPath)
+			return nil
+		}
+
+		if err := ioutil.WriteFile(actualPath, shot, 0644); err != nil {
+			return err
+		}
+		if err := writePNG(diffPath, diff.Image); err != nil {
+			return err
+		}
+		ae.Subject = fmt.Sprintf("%s, %d different pixels in %s", path, diff.Different, diffPath)
+		ae.Actual = fmt.Sprintf("%.4f%%", diff.Ratio()*100)
+
+		return sm.assertFailed(ae)
+	})
+	if sm.activeGroup != "" {
+		sm.addGroupAction("AssertScreenshot", []interface{}{name}, action)
+		return nil
+	}
+
+	err := sm.DoTimeoutContext(timeoutSec, false, action)
+	sm.Error(err, handleError)
+
+	return err
+}
+
+// CompareImages compares the images pixel by pixel, the pixels of the ignored regions are not counted.
+// If the sizes differ, the diff covers both images and the pixels missing from one of them are different.
+func CompareImages(baseline image.Image, actual image.Image, tolerance uint8, ignore []image.Rectangle) VisualDiff {
+	a, b := toNRGBA(baseline), toNRGBA(actual)
+	width, height := a.Bounds().Dx(), a.Bounds().Dy()
+	if b.Bounds().Dx() > width {
+		width = b.Bounds().Dx()
+	}
+	if b.Bounds().Dy() > height {
+		height = b.Bounds().Dy()
+	}
+	diff := VisualDiff{Image: image.NewNRGBA(image.Rect(0, 0, width, height))}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := image.Pt(x, y)
+			if ignored(p, ignore) {
+				diff.Image.Set(x, y, color.NRGBA{R: 0, G: 0, B: 255, A: 64})
+				continue
+			}
+			diff.Pixels++
+
+			if !p.In(a.Bounds()) || !p.In(b.Bounds()) {
+				diff.Different++
+				diff.Image.Set(x, y, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
+				continue
+			}
+
+			pa, pb := a.Pix[a.PixOffset(x, y):][:4], b.Pix[b.PixOffset(x, y):][:4]
+			if channelsDiffer(pa, pb, tolerance) {
+				diff.Different++
+				diff.Image.Set(x, y, color.NRGBA{R: 255, G: 0, B: 0, A: 255})
+				continue
+			}
+
+			// the unchanged pixels are faded, so the red ones stand out
+			gray := uint8((299*uint32(pa[0]) + 587*uint32(pa[1]) + 114*uint32(pa[2])) / 1000)
+			faded := 255 - (255-gray)/4
+			diff.Image.Set(x, y, color.NRGBA{R: faded, G: faded, B: faded, A: 255})
+		}
+	}
+
+	return diff
+}
+
+func channelsDiffer(a []uint8, b []uint8, tolerance uint8) bool {
+	for c := 0; c < 4; c++ {
+		d := int(a[c]) - int(b[c])
+		if d < 0 {
+			d = -d
+		}
+		if d > int(tolerance) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ignored(p image.Point, regions []image.Rectangle) bool {
+	for _, r := range regions {
+		if p.In(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toNRGBA copies the image into an NRGBA image starting at 0,0, so the pixels of two images have the same offsets
+func toNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+
+	return out
+}
+
+// pathName makes the name usable as a file name
+func pathName(name string) string {
+	name = strings.Trim(unsafePathChars.ReplaceAllString(name, "-"), "-.")
+	if name == "" {
+		return "default"
+	}
+
+	return name
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func removeFiles(paths ...string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,106 @@
+package base
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func filledImage(width, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestCompareImages(t *testing.T) {
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+	changed := filledImage(10, 10, white)
+	for x := 0; x < 10; x++ {
+		changed.SetNRGBA(x, 0, color.NRGBA{R: 0, G: 0, B: 0, A: 255})
+	}
+	noisy := filledImage(10, 10, color.NRGBA{R: 250, G: 252, B: 255, A: 255})
+
+	// the image of the actual screenshot may not start at 0,0
+	shifted := image.NewNRGBA(image.Rect(5, 5, 15, 15))
+	for i := range shifted.Pix {
+		shifted.Pix[i] = 255
+	}
+
+	tests := []struct {
+		name          string
+		actual        image.Image
+		tolerance     uint8
+		ignore        []image.Rectangle
+		wantPixels    int
+		wantDifferent int
+		wantBounds    image.Rectangle
+	}{
+		{"same", filledImage(10, 10, white), 0, nil, 100, 0, image.Rect(0, 0, 10, 10)},
+		{"changed row", changed, 0, nil, 100, 10, image.Rect(0, 0, 10, 10)},
+		{"noise over the tolerance", noisy, 4, nil, 100, 100, image.Rect(0, 0, 10, 10)},
+		{"noise in the tolerance", noisy, 5, nil, 100, 0, image.Rect(0, 0, 10, 10)},
+		{"changed row ignored", changed, 0, []image.Rectangle{image.Rect(0, 0, 10, 1)}, 90, 0, image.Rect(0, 0, 10, 10)},
+		{"half of the changed row ignored", changed, 0, []image.Rectangle{image.Rect(0, 0, 5, 1)}, 95, 5, image.Rect(0, 0, 10, 10)},
+		{"shifted bounds", shifted, 0, nil, 100, 0, image.Rect(0, 0, 10, 10)},
+		// the pixels missing from one of the images are different
+		{"smaller", filledImage(10, 8, white), 0, nil, 100, 20, image.Rect(0, 0, 10, 10)},
+		{"wider", filledImage(12, 10, white), 0, nil, 120, 20, image.Rect(0, 0, 12, 10)},
+		{"larger and ignored", filledImage(12, 12, white), 0, []image.Rectangle{image.Rect(10, 0, 12, 12), image.Rect(0, 10, 10, 12)}, 100, 0, image.Rect(0, 0, 12, 12)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := CompareImages(filledImage(10, 10, white), tt.actual, tt.tolerance, tt.ignore)
+			if diff.Pixels != tt.wantPixels || diff.Different != tt.wantDifferent {
+				t.Errorf("CompareImages() = %d of %d different, want %d of %d", diff.Different, diff.Pixels, tt.wantDifferent, tt.wantPixels)
+			}
+			if diff.Image.Bounds() != tt.wantBounds {
+				t.Errorf("diff image bounds = %v", diff.Image.Bounds())
+			}
+		})
+	}
+}
+
+func TestVisualDiffRatio(t *testing.T) {
+	tests := []struct {
+		diff VisualDiff
+		want float64
+	}{
+		{VisualDiff{}, 0},
+		{VisualDiff{Pixels: 200, Different: 0}, 0},
+		{VisualDiff{Pixels: 200, Different: 50}, 0.25},
+		{VisualDiff{Pixels: 200, Different: 200}, 1},
+	}
+
+	for _, tt := range tests {
+		if got := tt.diff.Ratio(); got != tt.want {
+			t.Errorf("%+v.Ratio() = %v, want %v", tt.diff, got, tt.want)
+		}
+	}
+}
+
+func TestPathName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"home", "home"},
+		{"Sony Xpereia XZ Premium", "Sony-Xpereia-XZ-Premium"},
+		{"login/with ../password", "login-with-..-password"},
+		{"..", "default"},
+		{"", "default"},
+		{"step_1.v2", "step_1.v2"},
+	}
+
+	for _, tt := range tests {
+		if got := pathName(tt.name); got != tt.want {
+			t.Errorf("pathName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	watat "github.com/dombiistvan/webdice-atat/base"
+	"os"
+)
+
+const usage = `usage: watat run [flags] scenario.yaml [scenario.json ...]
+
+flags:
+`
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "run" {
+		fmt.Fprint(os.Stderr, usage)
+		runFlags().PrintDefaults()
+		os.Exit(2)
+	}
+
+	os.Exit(run(os.Args[2:]))
+}
+
+type runOptions struct {
+	headless         bool
+	ignoreCertErrors bool
+	timeout          int64
+	device           string
+	parallel         int
+	isolated         bool
+	junit            string
+	json             string
+	html             string
+	baselines        string
+	updateBaselines  bool
+}
+
+var options runOptions
+
+func runFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.BoolVar(&options.headless, "headless", true, "run the browser without window")
+	fs.BoolVar(&options.ignoreCertErrors, "ignore-cert-errors", false, "ignore the certificate errors of the sites")
+	fs.Int64Var(&options.timeout, "timeout", 60, "default timeout of the browser session in seconds")
+	fs.StringVar(&options.device, "device", "", "device to emulate, overrides the device of the scenarios (pc, sony-xperia-xz-premium)")
+	fs.IntVar(&options.parallel, "parallel", 1, "number of scenarios running at the same time")
+	fs.BoolVar(&options.isolated, "isolated", false, "with -parallel, run the scenarios in incognito contexts of one browser instead of a browser each")
+	fs.StringVar(&options.junit, "junit", "", "write a JUnit XML report into this file")
+	fs.StringVar(&options.json, "json", "", "write a JSON report into this file")
+	fs.StringVar(&options.html, "html", "", "write a HTML report into this file")
+	fs.StringVar(&options.baselines, "baselines", "baselines", "directory of the baseline images of the assertScreenshot steps")
+	fs.BoolVar(&options.updateBaselines, "update-baselines", false, "save the screenshots of the assertScreenshot steps as the new baselines")
+
+	return fs
+}
+
+// run executes the scenarios one after the other, every scenario gets its own browser, or on a pool with -parallel
+func run(args []string) int {
+	fs := runFlags()
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+		return 2
+	}
+
+	report := watat.NewReport("watat")
+	exitCode := 0
+
+	var scenarios []watat.Scenario
+	for _, path := range fs.Args() {
+		sc, err := watat.LoadScenario(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			continue
+		}
+		scenarios = append(scenarios, sc)
+	}
+
+	if options.parallel > 1 {
+		if err := runParallel(scenarios, report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+		}
+	} else {
+		for _, sc := range scenarios {
+			if err := runScenario(sc, report); err != nil {
+				fmt.Fprintf(os.Stderr, "FAIL %s: %v\n", sc.Name, err)
+				exitCode = 1
+				continue
+			}
+
+			fmt.Printf("PASS %s\n", sc.Name)
+		}
+	}
+
+	if err := writeReports(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+	}
+
+	return exitCode
+}
+
+func runScenario(sc watat.Scenario, report *watat.Report) error {
+	if options.device != "" {
+		// RunScenario emulates the device of the scenario
+		sc.Device = options.device
+	}
+
+	d, err := watat.DeviceByName(sc.Device)
+	if err != nil {
+		return err
+	}
+
+	sm, err := watat.New(
+		watat.WithDevice(d),
+		watat.WithTimeout(options.timeout),
+		watat.WithHeadless(options.headless),
+		watat.WithIgnoreCertErrors(options.ignoreCertErrors),
+	)
+	if err != nil {
+		return err
+	}
+	defer sm.Cancel()
+
+	sm.SetReport(report)
+	sm.SetFailureScreenshot(true)
+	sm.SetVisualBaselines(options.baselines, options.updateBaselines)
+
+	result, err := sm.RunScenario(sc, false)
+	if result != nil {
+		fmt.Println(result)
+	}
+
+	return err
+}
+
+// runParallel executes the scenarios on a pool of browsers, it returns an error if any of them failed
+func runParallel(scenarios []watat.Scenario, report *watat.Report) error {
+	mode := watat.PoolBrowsers
+	if options.isolated {
+		mode = watat.PoolTargets
+	}
+
+	size := options.parallel
+	if size > len(scenarios) {
+		size = len(scenarios)
+	}
+	if size == 0 {
+		return nil
+	}
+
+	pool, err := watat.NewPool(size, mode,
+		watat.WithTimeout(options.timeout),
+		watat.WithHeadless(options.headless),
+		watat.WithIgnoreCertErrors(options.ignoreCertErrors),
+	)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	pool.SetScenarioSetup(func(sm *watat.SiteManager) {
+		sm.SetFailureScreenshot(true)
+		sm.SetVisualBaselines(options.baselines, options.updateBaselines)
+	})
+
+	if options.device != "" {
+		for i := range scenarios {
+			scenarios[i].Device = options.device
+		}
+	}
+
+	failed := 0
+	for _, sr := range pool.RunScenarios(scenarios, 0, report) {
+		if sr.Result != nil {
+			fmt.Println(sr.Result)
+		}
+
+		if sr.Err != nil {
+			fmt.Fprintf(os.Stderr, "FAIL %s: %v\n", sr.Scenario, sr.Err)
+			failed++
+			continue
+		}
+
+		fmt.Printf("PASS %s\n", sr.Scenario)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scenarios failed", failed, len(scenarios))
+	}
+
+	return nil
+}
+
+func writeReports(report *watat.Report) error {
+	if options.junit != "" {
+		if err := report.WriteJUnit(options.junit); err != nil {
+			return err
+		}
+	}
+
+	if options.json != "" {
+		if err := report.WriteJSON(options.json); err != nil {
+			return err
+		}
+	}
+
+	if options.html != "" {
+		if err := report.WriteHTML(options.html); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}